@@ -0,0 +1,261 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/slog"
+)
+
+var ErrOpen = errors.New("circuit breaker is open")
+
+//go:generate mockery --name=Sender --case underscore --with-expecter
+type Sender interface {
+	Send(req *http.Request) ([]byte, string, error)
+}
+
+type State int
+
+const (
+	Closed State = iota
+	Open
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(s))
+	}
+}
+
+func (s State) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Settings control when the breaker trips and how it recovers.
+type Settings struct {
+	// Interval is the length of the window in which failures are counted while closed.
+	Interval time.Duration
+	// MinRequests is the number of requests within the window required before the breaker may trip.
+	MinRequests int
+	// FailureRatio is the ratio of failed requests within the window that trips the breaker.
+	FailureRatio float64
+	// Cooldown is how long the breaker stays open before letting probe requests through.
+	Cooldown time.Duration
+	// HalfOpenMaxRequests is the number of concurrent probe requests allowed while half-open.
+	HalfOpenMaxRequests int
+}
+
+func DefaultSettings() Settings {
+	return Settings{
+		Interval:            time.Minute,
+		MinRequests:         5,
+		FailureRatio:        0.5,
+		Cooldown:            30 * time.Second,
+		HalfOpenMaxRequests: 1,
+	}
+}
+
+// Status is a snapshot of the breaker.
+type Status struct {
+	State    State      `json:"state"`
+	Requests int        `json:"requests"`
+	Failures int        `json:"failures"`
+	OpenedAt *time.Time `json:"opened_at,omitempty"`
+}
+
+// Breaker stops forwarding requests to a failing sender and rejects them with ErrOpen instead.
+type Breaker struct {
+	sender   Sender
+	settings Settings
+	now      func() time.Time
+
+	mu          sync.Mutex
+	state       State
+	windowStart time.Time
+	requests    int
+	failures    int
+	openedAt    time.Time
+	probes      int
+	// generation changes with the state, so that requests let through in an
+	// earlier state do not count towards the current one
+	generation uint64
+}
+
+func New(sender Sender, settings Settings) *Breaker {
+	b := &Breaker{
+		sender:   sender,
+		settings: settings,
+		now:      time.Now,
+	}
+	b.windowStart = b.now()
+	return b
+}
+
+func (b *Breaker) Send(req *http.Request) ([]byte, string, error) {
+	generation, err := b.acquire()
+	if err != nil {
+		return nil, "", err
+	}
+
+	payload, contentType, err := b.sender.Send(req)
+	b.release(generation, classify(req.Context(), err))
+	return payload, contentType, err
+}
+
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance()
+	return b.state
+}
+
+// Ready reports ErrOpen while requests are rejected.
+func (b *Breaker) Ready() error {
+	if b.State() == Open {
+		return ErrOpen
+	}
+	return nil
+}
+
+func (b *Breaker) Status() any {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance()
+	status := Status{
+		State:    b.state,
+		Requests: b.requests,
+		Failures: b.failures,
+	}
+	if !b.openedAt.IsZero() {
+		openedAt := b.openedAt
+		status.OpenedAt = &openedAt
+	}
+	return status
+}
+
+// acquire lets a request through, taking a probe slot while half-open, and
+// returns the generation it was let through in.
+func (b *Breaker) acquire() (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance()
+
+	switch b.state {
+	case Open:
+		return 0, fmt.Errorf("%w until %s", ErrOpen, b.openedAt.Add(b.settings.Cooldown).Format(time.RFC3339))
+	case HalfOpen:
+		if b.probes >= b.settings.HalfOpenMaxRequests {
+			return 0, fmt.Errorf("%w: probe in progress", ErrOpen)
+		}
+		b.probes++
+	}
+	return b.generation, nil
+}
+
+func (b *Breaker) release(generation uint64, result outcome) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if generation != b.generation {
+		// the state changed while the request was in flight, its probe slot
+		// is gone and its outcome belongs to the earlier state
+		return
+	}
+	switch b.state {
+	case HalfOpen:
+		b.probes--
+		switch result {
+		case abandoned:
+		case failed:
+			b.trip()
+		default:
+			b.setState(Closed)
+		}
+	case Closed:
+		if result == abandoned {
+			return
+		}
+		b.requests++
+		if result == failed {
+			b.failures++
+		}
+		if b.requests >= b.settings.MinRequests &&
+			float64(b.failures)/float64(b.requests) >= b.settings.FailureRatio {
+			b.trip()
+		}
+	}
+}
+
+// advance moves the breaker along the time axis: it starts a new counting
+// window while closed and lets probes through once the cooldown is over.
+func (b *Breaker) advance() {
+	now := b.now()
+	switch b.state {
+	case Closed:
+		if b.settings.Interval > 0 && now.Sub(b.windowStart) >= b.settings.Interval {
+			b.resetCounts()
+		}
+	case Open:
+		if now.Sub(b.openedAt) >= b.settings.Cooldown {
+			b.setState(HalfOpen)
+		}
+	}
+}
+
+func (b *Breaker) trip() {
+	b.openedAt = b.now()
+	b.setState(Open)
+}
+
+func (b *Breaker) setState(state State) {
+	if b.state == state {
+		return
+	}
+	slog.Warn("circuit breaker changes state", "from", b.state, "to", state)
+	b.state = state
+	b.generation++
+	b.probes = 0
+	b.resetCounts()
+	if state == Closed {
+		b.openedAt = time.Time{}
+	}
+}
+
+func (b *Breaker) resetCounts() {
+	b.windowStart = b.now()
+	b.requests = 0
+	b.failures = 0
+}
+
+type outcome int
+
+const (
+	succeeded outcome = iota
+	failed
+	// abandoned requests were cancelled by their caller and say nothing about
+	// the health of the upstream.
+	abandoned
+)
+
+func classify(ctx context.Context, err error) outcome {
+	switch {
+	case err == nil:
+		return succeeded
+	case errors.Is(ctx.Err(), context.Canceled):
+		return abandoned
+	default:
+		return failed
+	}
+}
@@ -0,0 +1,380 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/koenno/standard-deviation-service/breaker/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func newTestBreaker(sender Sender, clock *fakeClock) *Breaker {
+	b := New(sender, Settings{
+		Interval:            time.Minute,
+		MinRequests:         4,
+		FailureRatio:        0.5,
+		Cooldown:            10 * time.Second,
+		HalfOpenMaxRequests: 1,
+	})
+	b.now = clock.Now
+	b.windowStart = clock.Now()
+	return b
+}
+
+func TestShouldForwardRequestsWhileClosed(t *testing.T) {
+	// given
+	senderMock := mocks.NewSender(t)
+	clock := &fakeClock{now: time.Now()}
+	sut := newTestBreaker(senderMock, clock)
+	req, _ := http.NewRequest(http.MethodGet, "some.domain.com", nil)
+
+	senderMock.EXPECT().Send(req).Return([]byte("1"), "text/plain", nil).Once()
+
+	// when
+	payload, contentType, err := sut.Send(req)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("1"), payload)
+	assert.Equal(t, "text/plain", contentType)
+	assert.Equal(t, Closed, sut.State())
+}
+
+func TestShouldOpenWhenFailureRatioIsReached(t *testing.T) {
+	// given
+	senderMock := mocks.NewSender(t)
+	clock := &fakeClock{now: time.Now()}
+	sut := newTestBreaker(senderMock, clock)
+	req, _ := http.NewRequest(http.MethodGet, "some.domain.com", nil)
+
+	senderMock.EXPECT().Send(req).Return([]byte("1"), "text/plain", nil).Twice()
+	senderMock.EXPECT().Send(req).Return(nil, "", errors.New("failure")).Twice()
+
+	// when
+	for i := 0; i < 4; i++ {
+		sut.Send(req)
+	}
+	_, _, err := sut.Send(req)
+
+	// then
+	assert.ErrorIs(t, err, ErrOpen)
+	assert.Equal(t, Open, sut.State())
+	senderMock.AssertNumberOfCalls(t, "Send", 4)
+}
+
+func TestShouldNotOpenBelowMinRequests(t *testing.T) {
+	// given
+	senderMock := mocks.NewSender(t)
+	clock := &fakeClock{now: time.Now()}
+	sut := newTestBreaker(senderMock, clock)
+	req, _ := http.NewRequest(http.MethodGet, "some.domain.com", nil)
+
+	senderMock.EXPECT().Send(req).Return(nil, "", errors.New("failure")).Times(3)
+
+	// when
+	for i := 0; i < 3; i++ {
+		sut.Send(req)
+	}
+
+	// then
+	assert.Equal(t, Closed, sut.State())
+}
+
+func TestShouldForgetFailuresOfPreviousInterval(t *testing.T) {
+	// given
+	senderMock := mocks.NewSender(t)
+	clock := &fakeClock{now: time.Now()}
+	sut := newTestBreaker(senderMock, clock)
+	req, _ := http.NewRequest(http.MethodGet, "some.domain.com", nil)
+
+	senderMock.EXPECT().Send(req).Return(nil, "", errors.New("failure")).Times(4)
+
+	// when
+	for i := 0; i < 3; i++ {
+		sut.Send(req)
+	}
+	clock.Advance(time.Minute)
+	sut.Send(req)
+
+	// then
+	assert.Equal(t, Closed, sut.State())
+	assert.Equal(t, Status{State: Closed, Requests: 1, Failures: 1}, sut.Status())
+}
+
+func TestShouldNotCountCanceledRequests(t *testing.T) {
+	// given
+	senderMock := mocks.NewSender(t)
+	clock := &fakeClock{now: time.Now()}
+	sut := newTestBreaker(senderMock, clock)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "some.domain.com", nil)
+
+	senderMock.EXPECT().Send(req).Return(nil, "", context.Canceled).Times(4)
+
+	// when
+	for i := 0; i < 4; i++ {
+		sut.Send(req)
+	}
+
+	// then
+	assert.Equal(t, Closed, sut.State())
+	assert.Equal(t, 0, sut.Status().(Status).Requests)
+}
+
+func TestShouldCloseAfterSuccessfulProbe(t *testing.T) {
+	// given
+	senderMock := mocks.NewSender(t)
+	clock := &fakeClock{now: time.Now()}
+	sut := newTestBreaker(senderMock, clock)
+	req, _ := http.NewRequest(http.MethodGet, "some.domain.com", nil)
+
+	senderMock.EXPECT().Send(req).Return(nil, "", errors.New("failure")).Times(4)
+	for i := 0; i < 4; i++ {
+		sut.Send(req)
+	}
+	senderMock.EXPECT().Send(req).Return([]byte("1"), "text/plain", nil).Once()
+
+	// when
+	clock.Advance(10 * time.Second)
+	stateAfterCooldown := sut.State()
+	_, _, err := sut.Send(req)
+
+	// then
+	assert.Equal(t, HalfOpen, stateAfterCooldown)
+	assert.NoError(t, err)
+	assert.Equal(t, Closed, sut.State())
+}
+
+func TestShouldReopenAfterFailedProbe(t *testing.T) {
+	// given
+	senderMock := mocks.NewSender(t)
+	clock := &fakeClock{now: time.Now()}
+	sut := newTestBreaker(senderMock, clock)
+	req, _ := http.NewRequest(http.MethodGet, "some.domain.com", nil)
+
+	senderMock.EXPECT().Send(req).Return(nil, "", errors.New("failure")).Times(5)
+	for i := 0; i < 4; i++ {
+		sut.Send(req)
+	}
+
+	// when
+	clock.Advance(10 * time.Second)
+	sut.Send(req)
+
+	// then
+	assert.Equal(t, Open, sut.State())
+	status := sut.Status().(Status)
+	assert.Equal(t, clock.Now(), *status.OpenedAt)
+}
+
+func TestShouldStayHalfOpenAfterAbandonedProbe(t *testing.T) {
+	// given
+	senderMock := mocks.NewSender(t)
+	clock := &fakeClock{now: time.Now()}
+	sut := newTestBreaker(senderMock, clock)
+	req, _ := http.NewRequest(http.MethodGet, "some.domain.com", nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	abandonedReq, _ := http.NewRequestWithContext(ctx, http.MethodGet, "some.domain.com", nil)
+
+	senderMock.EXPECT().Send(req).Return(nil, "", errors.New("failure")).Times(4)
+	for i := 0; i < 4; i++ {
+		sut.Send(req)
+	}
+	clock.Advance(10 * time.Second)
+	senderMock.EXPECT().Send(abandonedReq).Return(nil, "", context.Canceled).Once()
+
+	// when
+	_, _, err := sut.Send(abandonedReq)
+
+	// then
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, HalfOpen, sut.State())
+	senderMock.EXPECT().Send(req).Return([]byte("1"), "text/plain", nil).Once()
+	_, _, probeErr := sut.Send(req)
+	assert.NoError(t, probeErr)
+	assert.Equal(t, Closed, sut.State())
+}
+
+func TestShouldLimitConcurrentProbes(t *testing.T) {
+	// given
+	senderMock := mocks.NewSender(t)
+	clock := &fakeClock{now: time.Now()}
+	sut := newTestBreaker(senderMock, clock)
+	req, _ := http.NewRequest(http.MethodGet, "some.domain.com", nil)
+
+	senderMock.EXPECT().Send(req).Return(nil, "", errors.New("failure")).Times(4)
+	for i := 0; i < 4; i++ {
+		sut.Send(req)
+	}
+	clock.Advance(10 * time.Second)
+
+	probeStarted := make(chan struct{})
+	probeRelease := make(chan struct{})
+	senderMock.EXPECT().Send(mock.Anything).Run(func(req *http.Request) {
+		close(probeStarted)
+		<-probeRelease
+	}).Return([]byte("1"), "text/plain", nil).Once()
+	go sut.Send(req)
+	<-probeStarted
+
+	// when
+	_, _, err := sut.Send(req)
+	close(probeRelease)
+
+	// then
+	assert.ErrorIs(t, err, ErrOpen)
+}
+
+func TestShouldLetSingleProbeThroughParallelSends(t *testing.T) {
+	// given
+	senderMock := mocks.NewSender(t)
+	clock := &fakeClock{now: time.Now()}
+	sut := newTestBreaker(senderMock, clock)
+	req, _ := http.NewRequest(http.MethodGet, "some.domain.com", nil)
+
+	senderMock.EXPECT().Send(req).Return(nil, "", errors.New("failure")).Times(4)
+	for i := 0; i < 4; i++ {
+		sut.Send(req)
+	}
+	clock.Advance(10 * time.Second)
+
+	probeRelease := make(chan struct{})
+	senderMock.EXPECT().Send(req).Run(func(req *http.Request) {
+		<-probeRelease
+	}).Return([]byte("1"), "text/plain", nil).Once()
+	const senders = 20
+	start := make(chan struct{})
+	results := make(chan error, senders)
+	var wg sync.WaitGroup
+	for i := 0; i < senders; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			_, _, err := sut.Send(req)
+			results <- err
+		}()
+	}
+
+	// when
+	close(start)
+	var rejected int
+	for rejected < senders-1 {
+		select {
+		case err := <-results:
+			assert.ErrorIs(t, err, ErrOpen)
+			rejected++
+		case <-time.After(5 * time.Second):
+			t.Fatalf("only %d of %d parallel sends were rejected while the probe is in progress", rejected, senders-1)
+		}
+	}
+	close(probeRelease)
+	wg.Wait()
+
+	// then
+	assert.NoError(t, <-results)
+	assert.Equal(t, Closed, sut.State())
+}
+
+func TestShouldIgnoreOutcomeOfRequestsFromEarlierState(t *testing.T) {
+	// given
+	senderMock := mocks.NewSender(t)
+	clock := &fakeClock{now: time.Now()}
+	sut := newTestBreaker(senderMock, clock)
+	slowReq, _ := http.NewRequest(http.MethodGet, "slow.domain.com", nil)
+	probeReq, _ := http.NewRequest(http.MethodGet, "probe.domain.com", nil)
+	req, _ := http.NewRequest(http.MethodGet, "some.domain.com", nil)
+
+	slowStarted := make(chan struct{})
+	slowRelease := make(chan struct{})
+	slowDone := make(chan struct{})
+	senderMock.EXPECT().Send(slowReq).Run(func(req *http.Request) {
+		close(slowStarted)
+		<-slowRelease
+	}).Return([]byte("1"), "text/plain", nil).Once()
+	go func() {
+		defer close(slowDone)
+		sut.Send(slowReq)
+	}()
+	<-slowStarted
+	senderMock.EXPECT().Send(req).Return(nil, "", errors.New("failure")).Times(4)
+	for i := 0; i < 4; i++ {
+		sut.Send(req)
+	}
+	clock.Advance(10 * time.Second)
+
+	probeStarted := make(chan struct{})
+	probeRelease := make(chan struct{})
+	probeDone := make(chan struct{})
+	senderMock.EXPECT().Send(probeReq).Run(func(req *http.Request) {
+		close(probeStarted)
+		<-probeRelease
+	}).Return([]byte("1"), "text/plain", nil).Once()
+	go func() {
+		defer close(probeDone)
+		sut.Send(probeReq)
+	}()
+	<-probeStarted
+
+	// when
+	close(slowRelease)
+	<-slowDone
+	stateAfterSlowRequest := sut.State()
+	_, _, err := sut.Send(req)
+	close(probeRelease)
+	<-probeDone
+
+	// then
+	assert.Equal(t, HalfOpen, stateAfterSlowRequest)
+	assert.ErrorIs(t, err, ErrOpen)
+	assert.Equal(t, Closed, sut.State())
+}
+
+func TestShouldReturnStateNames(t *testing.T) {
+	assert.Equal(t, "closed", Closed.String())
+	assert.Equal(t, "open", Open.String())
+	assert.Equal(t, "half-open", HalfOpen.String())
+}
+
+func TestShouldBeReadyUnlessOpen(t *testing.T) {
+	// given
+	senderMock := mocks.NewSender(t)
+	clock := &fakeClock{now: time.Now()}
+	sut := newTestBreaker(senderMock, clock)
+	req, _ := http.NewRequest(http.MethodGet, "some.domain.com", nil)
+
+	senderMock.EXPECT().Send(req).Return(nil, "", errors.New("failure")).Times(4)
+
+	// when
+	readyBefore := sut.Ready()
+	for i := 0; i < 4; i++ {
+		sut.Send(req)
+	}
+	readyOpen := sut.Ready()
+	clock.Advance(10 * time.Second)
+	readyHalfOpen := sut.Ready()
+
+	// then
+	assert.NoError(t, readyBefore)
+	assert.ErrorIs(t, readyOpen, ErrOpen)
+	assert.NoError(t, readyHalfOpen)
+}
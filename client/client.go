@@ -6,20 +6,47 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/koenno/standard-deviation-service/metrics"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/exp/slog"
 )
 
+const tracerName = "github.com/koenno/standard-deviation-service/client"
+
 var (
 	ErrSendRequest = errors.New("failed to send request")
 	ErrResponse    = errors.New("response failure")
+	// ErrRateLimit is returned when the rate limiter cannot grant a token,
+	// wrapping context.DeadlineExceeded when it would not before the request's
+	// deadline.
+	ErrRateLimit = errors.New("failed to limit a rate")
 
 	httpClient = &http.Client{
 		Timeout: 10 * time.Second,
 	}
 )
 
+// StatusError is returned for a response with a status other than 200 OK.
+type StatusError struct {
+	StatusCode  int
+	ContentType string
+	Body        []byte
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s: status code %d; body %s", ErrResponse, e.StatusCode, string(e.Body))
+}
+
+func (e *StatusError) Unwrap() error {
+	return ErrResponse
+}
+
 //go:generate mockery --name=RateLimiter --case underscore --with-expecter
 type RateLimiter interface {
 	Wait(ctx context.Context) (err error)
@@ -27,26 +54,125 @@ type RateLimiter interface {
 
 type Client struct {
 	rateLimiter RateLimiter
+	retryPolicy RetryPolicy
+	metrics     *metrics.Metrics
+	httpClient  *http.Client
+}
+
+type ClientOption func(*Client)
+
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(c *Client) {
+		c.retryPolicy = policy
+	}
+}
+
+// WithMetrics records upstream requests and rate limiter waits in the given metrics.
+func WithMetrics(m *metrics.Metrics) ClientOption {
+	return func(c *Client) {
+		c.metrics = m
+	}
+}
+
+// WithTimeout limits the time of a single attempt, including reading the response body.
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(c *Client) {
+		hc := *c.httpClient
+		hc.Timeout = timeout
+		c.httpClient = &hc
+	}
+}
+
+// WithTransport sends every attempt, retries included, through transport
+// instead of http.DefaultTransport.
+func WithTransport(transport http.RoundTripper) ClientOption {
+	return func(c *Client) {
+		hc := *c.httpClient
+		hc.Transport = transport
+		c.httpClient = &hc
+	}
 }
 
-func New(rateLimiter RateLimiter) Client {
-	return Client{
+func New(rateLimiter RateLimiter, opts ...ClientOption) Client {
+	c := Client{
 		rateLimiter: rateLimiter,
+		retryPolicy: NoRetry(),
+		metrics:     metrics.New(),
+		httpClient:  httpClient,
+	}
+	for _, o := range opts {
+		o(&c)
 	}
+	return c
 }
 
 func (c Client) Send(req *http.Request) ([]byte, string, error) {
+	ctx := req.Context()
+	for attempt := 1; ; attempt++ {
+		payload, contentType, retry, err := c.send(req)
+		if err == nil {
+			return payload, contentType, nil
+		}
+		if !retry.retryable || attempt >= c.retryPolicy.MaxAttempts || ctx.Err() != nil {
+			return nil, "", err
+		}
+
+		delay := c.retryPolicy.backoff(attempt)
+		if retry.after > 0 {
+			delay = min(retry.after, c.retryPolicy.MaxBackoff)
+		}
+		slog.Warn("client retries a request", "attempt", attempt, "delay", delay, "error", err)
+		if sleepErr := sleep(ctx, delay); sleepErr != nil {
+			return nil, "", fmt.Errorf("%w; retry aborted: %w", err, sleepErr)
+		}
+	}
+}
+
+type retryHint struct {
+	retryable bool
+	after     time.Duration
+}
+
+func (c Client) send(req *http.Request) ([]byte, string, retryHint, error) {
+	tracer := otel.Tracer(tracerName)
 	if c.rateLimiter != nil {
-		if err := c.rateLimiter.Wait(req.Context()); err != nil {
-			return nil, "", fmt.Errorf("failed to limit a rate: %v", err)
+		_, span := tracer.Start(req.Context(), "client.RateLimiter.Wait")
+		start := time.Now()
+		err := c.rateLimiter.Wait(req.Context())
+		c.metrics.RateLimiterWait.Observe(time.Since(start).Seconds())
+		if err != nil {
+			recordError(span, err)
+			span.End()
+			ctx := req.Context()
+			if _, ok := ctx.Deadline(); ok && ctx.Err() == nil {
+				// the limiter refuses to wait past the deadline
+				err = fmt.Errorf("%w: %w", context.DeadlineExceeded, err)
+			}
+			return nil, "", retryHint{}, fmt.Errorf("%w: %w", ErrRateLimit, err)
 		}
+		span.End()
+	}
+
+	attemptReq, err := rewind(req)
+	if err != nil {
+		return nil, "", retryHint{}, fmt.Errorf("%w: %w", ErrSendRequest, err)
 	}
 
 	slog.Info("client sends a request", "method", req.Method, "url", req.URL.String())
 
-	resp, err := httpClient.Do(req)
+	ctx, span := tracer.Start(attemptReq.Context(), "HTTP "+req.Method, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		semconv.HTTPRequestMethodKey.String(req.Method),
+		semconv.URLFull(req.URL.String()),
+	))
+	defer span.End()
+	attemptReq = attemptReq.WithContext(ctx)
+
+	start := time.Now()
+	resp, err := c.httpClient.Do(attemptReq)
 	if err != nil {
-		return nil, "", fmt.Errorf("%w: %v", ErrSendRequest, err)
+		c.observe("error", start)
+		recordError(span, err)
+		return nil, "", retryHint{retryable: true}, fmt.Errorf("%w: %w", ErrSendRequest, err)
 	}
 
 	defer func() {
@@ -56,13 +182,48 @@ func (c Client) Send(req *http.Request) ([]byte, string, error) {
 		}
 	}()
 	payloadBytes, err := io.ReadAll(resp.Body)
+	c.observe(strconv.Itoa(resp.StatusCode), start)
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+	}
 	if err != nil {
-		return nil, "", fmt.Errorf("%w: unable to read body: %v", ErrResponse, err)
+		return nil, "", retryHint{retryable: true}, fmt.Errorf("%w: unable to read body: %w", ErrResponse, err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, "", fmt.Errorf("%w: status code %d; body %s", ErrResponse, resp.StatusCode, string(payloadBytes))
+		hint := retryHint{retryable: retryableStatus(resp.StatusCode)}
+		hint.after, _ = retryAfter(resp.Header.Get("Retry-After"))
+		return nil, "", hint, &StatusError{
+			StatusCode:  resp.StatusCode,
+			ContentType: resp.Header.Get("content-type"),
+			Body:        payloadBytes,
+		}
 	}
 
-	return payloadBytes, resp.Header.Get("content-type"), nil
+	return payloadBytes, resp.Header.Get("content-type"), retryHint{}, nil
+}
+
+func (c Client) observe(status string, start time.Time) {
+	c.metrics.UpstreamRequests.WithLabelValues(status).Inc()
+	c.metrics.UpstreamDuration.WithLabelValues(status).Observe(time.Since(start).Seconds())
+}
+
+func recordError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// rewind returns a request with a fresh body so that it can be sent again.
+func rewind(req *http.Request) (*http.Request, error) {
+	if req.Body == nil || req.GetBody == nil {
+		return req, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, fmt.Errorf("unable to rewind body: %w", err)
+	}
+	attemptReq := req.Clone(req.Context())
+	attemptReq.Body = body
+	return attemptReq, nil
 }
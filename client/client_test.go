@@ -1,21 +1,30 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/koenno/standard-deviation-service/client/mocks"
+	"github.com/koenno/standard-deviation-service/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestShouldReturnErrorWhenResponseStatusCodeIsNotOK(t *testing.T) {
 	// given
 	limiterMock := mocks.NewRateLimiter(t)
 	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
 		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("no such page"))
 	}))
 	req, _ := http.NewRequest(http.MethodGet, fakeServer.URL, nil)
 	sut := New(limiterMock)
@@ -27,6 +36,13 @@ func TestShouldReturnErrorWhenResponseStatusCodeIsNotOK(t *testing.T) {
 
 	// then
 	assert.ErrorIs(t, err, ErrResponse)
+	assert.EqualError(t, err, "response failure: status code 404; body no such page")
+	var statusErr *StatusError
+	if assert.ErrorAs(t, err, &statusErr) {
+		assert.Equal(t, http.StatusNotFound, statusErr.StatusCode)
+		assert.Equal(t, "text/plain", statusErr.ContentType)
+		assert.Equal(t, []byte("no such page"), statusErr.Body)
+	}
 	assert.Zero(t, payload)
 	assert.Zero(t, contentType)
 }
@@ -54,3 +70,115 @@ func TestShouldReturnPayloadBytesAndContentTypeWhenNoError(t *testing.T) {
 	assert.Equal(t, expectedBytes, payload)
 	assert.Equal(t, expectedContentType, contentType)
 }
+
+func TestShouldRecordUpstreamRequestsByStatusCode(t *testing.T) {
+	// given
+	limiterMock := mocks.NewRateLimiter(t)
+	var calls atomic.Int32
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("4\n2\n"))
+	}))
+	defer fakeServer.Close()
+	req, _ := http.NewRequest(http.MethodGet, fakeServer.URL, nil)
+	m := metrics.New()
+	sut := New(limiterMock, WithRetryPolicy(fastRetryPolicy()), WithMetrics(m))
+
+	limiterMock.EXPECT().Wait(mock.Anything).Return(nil).Twice()
+
+	// when
+	_, _, err := sut.Send(req)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.UpstreamRequests.WithLabelValues("503")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.UpstreamRequests.WithLabelValues("200")))
+	assert.Equal(t, 2, testutil.CollectAndCount(m.UpstreamDuration))
+	assert.Equal(t, 1, testutil.CollectAndCount(m.RateLimiterWait))
+}
+
+func TestShouldRecordUpstreamNetworkErrors(t *testing.T) {
+	// given
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	fakeServer.Close()
+	req, _ := http.NewRequest(http.MethodGet, fakeServer.URL, nil)
+	m := metrics.New()
+	sut := New(nil, WithMetrics(m))
+
+	// when
+	_, _, err := sut.Send(req)
+
+	// then
+	assert.ErrorIs(t, err, ErrSendRequest)
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.UpstreamRequests.WithLabelValues("error")))
+}
+
+func TestShouldGiveUpAttemptAfterTimeout(t *testing.T) {
+	// given
+	release := make(chan struct{})
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer fakeServer.Close()
+	defer close(release)
+	req, _ := http.NewRequest(http.MethodGet, fakeServer.URL, nil)
+	sut := New(nil, WithTimeout(20*time.Millisecond))
+
+	// when
+	_, _, err := sut.Send(req)
+
+	// then
+	assert.ErrorIs(t, err, ErrSendRequest)
+}
+
+func TestShouldReportRateLimiterWaitBeyondDeadline(t *testing.T) {
+	tests := []struct {
+		name             string
+		ctx              func() (context.Context, context.CancelFunc)
+		expectedDeadline bool
+	}{
+		{
+			name: "wait would exceed deadline",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), time.Minute)
+			},
+			expectedDeadline: true,
+		},
+		{
+			name: "request cancelled",
+			ctx: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+				cancel()
+				return ctx, cancel
+			},
+			expectedDeadline: false,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// given
+			limiterMock := mocks.NewRateLimiter(t)
+			ctx, cancel := test.ctx()
+			defer cancel()
+			req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://some.domain.com", nil)
+			sut := New(limiterMock)
+
+			limiterMock.EXPECT().Wait(mock.Anything).RunAndReturn(func(ctx context.Context) error {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				return errors.New("rate: Wait(n=1) would exceed context deadline")
+			}).Once()
+
+			// when
+			_, _, err := sut.Send(req)
+
+			// then
+			assert.ErrorIs(t, err, ErrRateLimit)
+			assert.Equal(t, test.expectedDeadline, errors.Is(err, context.DeadlineExceeded))
+		})
+	}
+}
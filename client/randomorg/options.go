@@ -0,0 +1,55 @@
+package randomorg
+
+import "github.com/koenno/standard-deviation-service/client"
+
+const (
+	DefaultBaseURL = "https://www.random.org"
+	DefaultRPCURL  = "https://api.random.org/json-rpc/4/invoke"
+)
+
+// Option configures the request factories and the quota tracker.
+type Option func(*options)
+
+type options struct {
+	baseURL  string
+	rpcURL   string
+	defaults []client.Option
+}
+
+func newOptions(opts ...Option) options {
+	o := options{
+		baseURL: DefaultBaseURL,
+		rpcURL:  DefaultRPCURL,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithBaseURL points the plain-text /integers/ and /quota/ endpoints at another host,
+// e.g. a local fake of random.org.
+func WithBaseURL(baseURL string) Option {
+	return func(o *options) {
+		o.baseURL = baseURL
+	}
+}
+
+// WithRPCURL points the JSON-RPC factory at another endpoint.
+func WithRPCURL(rpcURL string) Option {
+	return func(o *options) {
+		o.rpcURL = rpcURL
+	}
+}
+
+// WithDefaults overrides client.Options defaults for requests built by the factories.
+// Options passed to NewRequest still take precedence.
+func WithDefaults(defaults ...client.Option) Option {
+	return func(o *options) {
+		o.defaults = defaults
+	}
+}
+
+func (o options) requestOptions(opts []client.Option) *client.Options {
+	return client.NewOptions(append(append([]client.Option{}, o.defaults...), opts...)...)
+}
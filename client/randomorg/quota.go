@@ -0,0 +1,218 @@
+package randomorg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/bits"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/slog"
+)
+
+var (
+	ErrQuotaExceeded = errors.New("random.org bit quota exceeded")
+	ErrUnreachable   = errors.New("random.org is unreachable")
+)
+
+//go:generate mockery --name=Sender --case underscore --with-expecter
+type Sender interface {
+	Send(req *http.Request) ([]byte, string, error)
+}
+
+// QuotaStatus is a snapshot of the tracked allowance.
+type QuotaStatus struct {
+	BitsLeft  int64      `json:"bits_left"`
+	Known     bool       `json:"known"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// QuotaTracker keeps track of the per-IP bit allowance of random.org and
+// refuses requests that would exceed it, so the client never gets banned for
+// requesting with a negative quota.
+type QuotaTracker struct {
+	sender   Sender
+	quotaURL string
+
+	mu         sync.Mutex
+	bitsLeft   int64
+	known      bool
+	updatedAt  time.Time
+	refreshErr error
+}
+
+func NewQuotaTracker(sender Sender, opts ...Option) *QuotaTracker {
+	return &QuotaTracker{
+		sender:   sender,
+		quotaURL: newOptions(opts...).baseURL + "/quota/?format=plain",
+	}
+}
+
+// Run refreshes the quota every interval until the context is done.
+func (q *QuotaTracker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		err := q.Refresh(ctx)
+		if err != nil {
+			slog.Error("failed to refresh random.org quota", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh reads the current allowance from the quota endpoint, through the
+// same sender as the requests it tracks.
+func (q *QuotaTracker) Refresh(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, q.quotaURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	payload, _, err := q.sender.Send(req)
+	if err != nil {
+		// an abandoned refresh says nothing about random.org
+		if ctx.Err() == nil {
+			q.setRefreshErr(err)
+		}
+		return err
+	}
+	bitsLeft, err := strconv.ParseInt(strings.TrimSpace(string(payload)), 10, 64)
+	if err != nil {
+		err = fmt.Errorf("failed to parse quota: %v", err)
+		q.setRefreshErr(err)
+		return err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.bitsLeft = bitsLeft
+	q.known = true
+	q.updatedAt = time.Now()
+	q.refreshErr = nil
+	return nil
+}
+
+func (q *QuotaTracker) setRefreshErr(err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.refreshErr = err
+}
+
+// Send forwards the request unless the bits it is estimated to consume exceed
+// the remaining allowance. Requests of unknown cost are forwarded as they are.
+func (q *QuotaTracker) Send(req *http.Request) ([]byte, string, error) {
+	cost, ok := RequestBits(req)
+	if ok {
+		err := q.reserve(cost)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	payload, contentType, err := q.sender.Send(req)
+	if err != nil && ok {
+		q.refund(cost)
+	}
+	return payload, contentType, err
+}
+
+// BitsLeft returns the remaining allowance and whether it has been read yet.
+func (q *QuotaTracker) BitsLeft() (int64, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.bitsLeft, q.known
+}
+
+// Ready reports ErrQuotaExceeded once the allowance is used up.
+func (q *QuotaTracker) Ready() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.known && q.bitsLeft <= 0 {
+		return fmt.Errorf("%w: %d bits left", ErrQuotaExceeded, q.bitsLeft)
+	}
+	return nil
+}
+
+// Reachable reports ErrUnreachable while the latest refresh failed.
+func (q *QuotaTracker) Reachable() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.refreshErr != nil {
+		return fmt.Errorf("%w: %w", ErrUnreachable, q.refreshErr)
+	}
+	return nil
+}
+
+func (q *QuotaTracker) Status() any {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	status := QuotaStatus{
+		BitsLeft: q.bitsLeft,
+		Known:    q.known,
+	}
+	if q.known {
+		updatedAt := q.updatedAt
+		status.UpdatedAt = &updatedAt
+	}
+	return status
+}
+
+func (q *QuotaTracker) reserve(cost int64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !q.known {
+		return nil
+	}
+	if q.bitsLeft < cost {
+		return fmt.Errorf("%w: %d bits left, %d bits requested", ErrQuotaExceeded, q.bitsLeft, cost)
+	}
+	q.bitsLeft -= cost
+	return nil
+}
+
+func (q *QuotaTracker) refund(cost int64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.known {
+		q.bitsLeft += cost
+	}
+}
+
+// RequestBits estimates the bits a request built by RequestFactory consumes.
+func RequestBits(req *http.Request) (int64, bool) {
+	if req.Method != http.MethodGet || !strings.HasSuffix(req.URL.Path, "/integers/") {
+		return 0, false
+	}
+	return queryBits(req.URL.Query())
+}
+
+func queryBits(query url.Values) (int64, bool) {
+	num, err := strconv.ParseInt(query.Get("num"), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	min, err := strconv.ParseInt(query.Get("min"), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	max, err := strconv.ParseInt(query.Get("max"), 10, 64)
+	if err != nil || max < min {
+		return 0, false
+	}
+	return EstimateBits(num, min, max), true
+}
+
+// EstimateBits returns the number of bits needed to draw num integers within [min, max].
+func EstimateBits(num, min, max int64) int64 {
+	span := uint64(max - min)
+	return num * int64(bits.Len64(span))
+}
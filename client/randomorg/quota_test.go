@@ -0,0 +1,279 @@
+package randomorg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/koenno/standard-deviation-service/client"
+	"github.com/koenno/standard-deviation-service/client/randomorg/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func expectQuota(senderMock *mocks.Sender, quota string) {
+	senderMock.EXPECT().Send(mock.MatchedBy(func(req *http.Request) bool {
+		return req.URL.Path == "/quota/" && req.URL.Query().Get("format") == "plain"
+	})).Return([]byte(quota+"\n"), "text/plain", nil).Once()
+}
+
+func TestShouldEstimateBits(t *testing.T) {
+	tests := []struct {
+		name     string
+		num      int64
+		min      int64
+		max      int64
+		expected int64
+	}{
+		{
+			name:     "single value",
+			num:      5,
+			min:      3,
+			max:      3,
+			expected: 0,
+		},
+		{
+			name:     "power of two values",
+			num:      5,
+			min:      1,
+			max:      8,
+			expected: 15,
+		},
+		{
+			name:     "default range",
+			num:      5,
+			min:      1,
+			max:      10,
+			expected: 20,
+		},
+		{
+			name:     "full range",
+			num:      1,
+			min:      -1_000_000_000,
+			max:      1_000_000_000,
+			expected: 31,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// when
+			res := EstimateBits(test.num, test.min, test.max)
+
+			// then
+			assert.Equal(t, test.expected, res)
+		})
+	}
+}
+
+func TestShouldEstimateBitsOfFactoryRequest(t *testing.T) {
+	// given
+	req, _ := NewRequestFactory().NewRequest(context.Background(),
+		client.WithQuantity(10), client.WithMin(0), client.WithMax(255))
+
+	// when
+	cost, ok := RequestBits(req)
+
+	// then
+	assert.True(t, ok)
+	assert.Equal(t, int64(80), cost)
+}
+
+func TestShouldNotEstimateBitsOfOtherRequests(t *testing.T) {
+	// given
+	req, _ := NewRPCRequestFactory("secret", false).NewRequest(context.Background())
+
+	// when
+	_, ok := RequestBits(req)
+
+	// then
+	assert.False(t, ok)
+}
+
+func TestShouldRefreshQuota(t *testing.T) {
+	// given
+	senderMock := mocks.NewSender(t)
+	expectQuota(senderMock, "1000")
+	sut := NewQuotaTracker(senderMock)
+
+	// when
+	err := sut.Refresh(context.Background())
+
+	// then
+	assert.NoError(t, err)
+	bitsLeft, known := sut.BitsLeft()
+	assert.True(t, known)
+	assert.Equal(t, int64(1000), bitsLeft)
+}
+
+func TestShouldForwardRequestsWhileQuotaIsUnknown(t *testing.T) {
+	// given
+	senderMock := mocks.NewSender(t)
+	sut := NewQuotaTracker(senderMock)
+	req, _ := NewRequestFactory().NewRequest(context.Background())
+
+	senderMock.EXPECT().Send(req).Return([]byte("1"), "text/plain", nil).Once()
+
+	// when
+	payload, _, err := sut.Send(req)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("1"), payload)
+}
+
+func TestShouldConsumeQuotaOfForwardedRequests(t *testing.T) {
+	// given
+	senderMock := mocks.NewSender(t)
+	expectQuota(senderMock, "100")
+	sut := NewQuotaTracker(senderMock)
+	assert.NoError(t, sut.Refresh(context.Background()))
+	req, _ := NewRequestFactory().NewRequest(context.Background(), client.WithQuantity(5), client.WithMin(1), client.WithMax(10))
+
+	senderMock.EXPECT().Send(req).Return([]byte("1"), "text/plain", nil).Once()
+
+	// when
+	_, _, err := sut.Send(req)
+
+	// then
+	assert.NoError(t, err)
+	bitsLeft, _ := sut.BitsLeft()
+	assert.Equal(t, int64(80), bitsLeft)
+}
+
+func TestShouldRefundQuotaOfFailedRequests(t *testing.T) {
+	// given
+	senderMock := mocks.NewSender(t)
+	expectQuota(senderMock, "100")
+	sut := NewQuotaTracker(senderMock)
+	assert.NoError(t, sut.Refresh(context.Background()))
+	req, _ := NewRequestFactory().NewRequest(context.Background(), client.WithQuantity(5), client.WithMin(1), client.WithMax(10))
+
+	senderMock.EXPECT().Send(req).Return(nil, "", errors.New("failure")).Once()
+
+	// when
+	_, _, err := sut.Send(req)
+
+	// then
+	assert.Error(t, err)
+	bitsLeft, _ := sut.BitsLeft()
+	assert.Equal(t, int64(100), bitsLeft)
+}
+
+func TestShouldRefuseRequestsExceedingQuota(t *testing.T) {
+	// given
+	senderMock := mocks.NewSender(t)
+	expectQuota(senderMock, "19")
+	sut := NewQuotaTracker(senderMock)
+	assert.NoError(t, sut.Refresh(context.Background()))
+	req, _ := NewRequestFactory().NewRequest(context.Background(), client.WithQuantity(5), client.WithMin(1), client.WithMax(10))
+
+	// when
+	payload, contentType, err := sut.Send(req)
+
+	// then
+	assert.ErrorIs(t, err, ErrQuotaExceeded)
+	assert.Zero(t, payload)
+	assert.Zero(t, contentType)
+	senderMock.AssertNotCalled(t, "Send")
+}
+
+func TestShouldKeepQuotaWhenRefreshFails(t *testing.T) {
+	// given
+	senderMock := mocks.NewSender(t)
+	expectQuota(senderMock, "not a number")
+	sut := NewQuotaTracker(senderMock)
+
+	// when
+	err := sut.Refresh(context.Background())
+
+	// then
+	assert.Error(t, err)
+	assert.Equal(t, QuotaStatus{}, sut.Status())
+}
+
+func TestShouldRefreshQuotaThroughSender(t *testing.T) {
+	// given
+	senderMock := mocks.NewSender(t)
+	sut := NewQuotaTracker(senderMock, WithBaseURL("http://random.test"))
+	responseErr := fmt.Errorf("%w: status code 503", client.ErrResponse)
+
+	senderMock.EXPECT().Send(mock.Anything).Run(func(req *http.Request) {
+		assert.Equal(t, "http://random.test/quota/?format=plain", req.URL.String())
+	}).Return(nil, "", responseErr).Once()
+
+	// when
+	err := sut.Refresh(context.Background())
+
+	// then
+	assert.ErrorIs(t, err, client.ErrResponse)
+	assert.Equal(t, QuotaStatus{}, sut.Status())
+}
+
+func TestShouldBeUnreachableWhileLatestRefreshFails(t *testing.T) {
+	// given
+	senderMock := mocks.NewSender(t)
+	sut := NewQuotaTracker(senderMock)
+	reachableBefore := sut.Reachable()
+
+	senderMock.EXPECT().Send(mock.Anything).Return(nil, "", client.ErrSendRequest).Once()
+	failedErr := sut.Refresh(context.Background())
+	unreachable := sut.Reachable()
+	expectQuota(senderMock, "1000")
+
+	// when
+	err := sut.Refresh(context.Background())
+
+	// then
+	assert.NoError(t, reachableBefore)
+	assert.ErrorIs(t, failedErr, client.ErrSendRequest)
+	assert.ErrorIs(t, unreachable, ErrUnreachable)
+	assert.ErrorIs(t, unreachable, client.ErrSendRequest)
+	assert.NoError(t, err)
+	assert.NoError(t, sut.Reachable())
+}
+
+func TestShouldBeReadyUntilQuotaIsExhausted(t *testing.T) {
+	tests := []struct {
+		name     string
+		quota    string
+		expected error
+	}{
+		{
+			name:     "bits left",
+			quota:    "1",
+			expected: nil,
+		},
+		{
+			name:     "no bits left",
+			quota:    "0",
+			expected: ErrQuotaExceeded,
+		},
+		{
+			name:     "negative quota",
+			quota:    "-150",
+			expected: ErrQuotaExceeded,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// given
+			senderMock := mocks.NewSender(t)
+			expectQuota(senderMock, test.quota)
+			sut := NewQuotaTracker(senderMock)
+			assert.NoError(t, sut.Ready())
+
+			// when
+			err := sut.Refresh(context.Background())
+
+			// then
+			assert.NoError(t, err)
+			if test.expected == nil {
+				assert.NoError(t, sut.Ready())
+			} else {
+				assert.ErrorIs(t, sut.Ready(), test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,281 @@
+// Package randomorgtest provides a fake of the random.org /integers/ and
+// /quota/ endpoints for integration tests and local development.
+package randomorgtest
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/koenno/standard-deviation-service/client/randomorg"
+)
+
+const (
+	// DefaultQuota is the daily bit allowance random.org grants every IP address.
+	DefaultQuota = 1_000_000
+
+	plainText = "text/plain;charset=UTF-8"
+)
+
+// Behaviour answers a single /integers/ request in place of the fake.
+// serve answers it as random.org would, drawing the requested integers.
+type Behaviour func(w http.ResponseWriter, r *http.Request, serve http.HandlerFunc)
+
+// Option configures a Handler.
+type Option func(*Handler)
+
+// WithQuota sets the bits left at start, DefaultQuota unless given.
+func WithQuota(bits int64) Option {
+	return func(h *Handler) {
+		h.bitsLeft = bits
+	}
+}
+
+// WithSeed makes the drawn integers reproducible.
+func WithSeed(seed uint64) Option {
+	return func(h *Handler) {
+		h.rnd = rand.New(rand.NewPCG(seed, seed))
+	}
+}
+
+// WithLatency delays every /integers/ response.
+func WithLatency(latency time.Duration) Option {
+	return func(h *Handler) {
+		h.latency = latency
+	}
+}
+
+// Handler serves GET /integers/ in the plain format and GET /quota/. Every
+// request served deducts the bits it drew from the quota and, like
+// random.org, requests are refused once the quota has gone negative.
+// Scripted behaviours answer the next requests instead, in order.
+type Handler struct {
+	latency time.Duration
+
+	mu       sync.Mutex
+	rnd      *rand.Rand
+	bitsLeft int64
+	script   []Behaviour
+	fallback Behaviour
+	requests int
+}
+
+func NewHandler(opts ...Option) *Handler {
+	h := &Handler{
+		rnd:      rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())),
+		bitsLeft: DefaultQuota,
+	}
+	for _, o := range opts {
+		o(h)
+	}
+	return h
+}
+
+// Enqueue scripts the answers to the next /integers/ requests.
+func (h *Handler) Enqueue(behaviours ...Behaviour) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.script = append(h.script, behaviours...)
+}
+
+// SetDefault answers every /integers/ request the script does not with
+// behaviour, or as random.org would when nil.
+func (h *Handler) SetDefault(behaviour Behaviour) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.fallback = behaviour
+}
+
+func (h *Handler) SetQuota(bits int64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.bitsLeft = bits
+}
+
+func (h *Handler) BitsLeft() int64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.bitsLeft
+}
+
+// Requests returns the number of /integers/ requests received.
+func (h *Handler) Requests() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.requests
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method != http.MethodGet:
+		http.Error(w, "Error: Method not allowed", http.StatusMethodNotAllowed)
+	case r.URL.Path == "/integers/":
+		h.behaviour()(w, r, h.serveIntegers)
+	case r.URL.Path == "/quota/":
+		w.Header().Set("Content-Type", plainText)
+		fmt.Fprintf(w, "%d\n", h.BitsLeft())
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *Handler) behaviour() Behaviour {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.requests++
+	if len(h.script) > 0 {
+		next := h.script[0]
+		h.script = h.script[1:]
+		return next
+	}
+	if h.fallback != nil {
+		return h.fallback
+	}
+	return Latency(h.latency)
+}
+
+func (h *Handler) serveIntegers(w http.ResponseWriter, r *http.Request) {
+	num, min, max, err := parseQuery(r)
+	if err != nil {
+		writeError(w, err.Error())
+		return
+	}
+
+	h.mu.Lock()
+	if h.bitsLeft < 0 {
+		h.mu.Unlock()
+		QuotaExhausted()(w, r, nil)
+		return
+	}
+	h.bitsLeft -= randomorg.EstimateBits(int64(num), int64(min), int64(max))
+	var body strings.Builder
+	for i := 0; i < num; i++ {
+		fmt.Fprintf(&body, "%d\n", min+h.rnd.IntN(max-min+1))
+	}
+	h.mu.Unlock()
+
+	w.Header().Set("Content-Type", plainText)
+	w.Write([]byte(body.String()))
+}
+
+func parseQuery(r *http.Request) (num, min, max int, err error) {
+	query := r.URL.Query()
+	if query.Get("format") != "plain" || query.Get("col") != "1" || query.Get("base") != "10" {
+		return 0, 0, 0, errors.New("Error: Only format=plain, col=1 and base=10 are supported")
+	}
+	num, err = strconv.Atoi(query.Get("num"))
+	if err != nil || num < 1 || num > 10_000 {
+		return 0, 0, 0, errors.New("Error: The number of random numbers (num) must be between 1 and 10,000")
+	}
+	min, err = strconv.Atoi(query.Get("min"))
+	if err != nil || min < -1_000_000_000 || min > 1_000_000_000 {
+		return 0, 0, 0, errors.New("Error: The minimum value (min) must be between -1,000,000,000 and 1,000,000,000")
+	}
+	max, err = strconv.Atoi(query.Get("max"))
+	if err != nil || max < -1_000_000_000 || max > 1_000_000_000 {
+		return 0, 0, 0, errors.New("Error: The maximum value (max) must be between -1,000,000,000 and 1,000,000,000")
+	}
+	if max < min {
+		return 0, 0, 0, errors.New("Error: The maximum value (max) must be greater than the minimum value (min)")
+	}
+	return num, min, max, nil
+}
+
+// writeError answers as random.org does for errors in the plain format.
+func writeError(w http.ResponseWriter, msg string) {
+	w.Header().Set("Content-Type", plainText)
+	w.WriteHeader(http.StatusServiceUnavailable)
+	fmt.Fprintln(w, msg)
+}
+
+// Latency delays the response by d, or less when the request is abandoned.
+func Latency(d time.Duration) Behaviour {
+	return func(w http.ResponseWriter, r *http.Request, serve http.HandlerFunc) {
+		if d > 0 {
+			timer := time.NewTimer(d)
+			defer timer.Stop()
+			select {
+			case <-r.Context().Done():
+				return
+			case <-timer.C:
+			}
+		}
+		serve(w, r)
+	}
+}
+
+// Status answers with the status code and its text as a plain body.
+func Status(code int) Behaviour {
+	return func(w http.ResponseWriter, r *http.Request, _ http.HandlerFunc) {
+		http.Error(w, http.StatusText(code), code)
+	}
+}
+
+// Unavailable answers 503, as random.org does during maintenance.
+func Unavailable() Behaviour {
+	return Status(http.StatusServiceUnavailable)
+}
+
+// HTMLErrorPage answers with the status code and an HTML page, as a proxy in
+// front of random.org would.
+func HTMLErrorPage(code int) Behaviour {
+	return func(w http.ResponseWriter, r *http.Request, _ http.HandlerFunc) {
+		w.Header().Set("Content-Type", "text/html; charset=UTF-8")
+		w.WriteHeader(code)
+		fmt.Fprintf(w, "<html><head><title>%d %s</title></head><body><h1>%s</h1></body></html>\n", code, http.StatusText(code), http.StatusText(code))
+	}
+}
+
+// HTMLPage answers 200 OK with an HTML page instead of the integers.
+func HTMLPage() Behaviour {
+	return func(w http.ResponseWriter, r *http.Request, _ http.HandlerFunc) {
+		w.Header().Set("Content-Type", "text/html; charset=UTF-8")
+		fmt.Fprintln(w, "<html><body><p>Please enable JavaScript.</p></body></html>")
+	}
+}
+
+// MalformedLines answers with the requested integers followed by a line
+// that is not an integer.
+func MalformedLines() Behaviour {
+	return func(w http.ResponseWriter, r *http.Request, serve http.HandlerFunc) {
+		rec := httptest.NewRecorder()
+		serve(rec, r)
+		for key, values := range rec.Header() {
+			w.Header()[key] = values
+		}
+		w.WriteHeader(rec.Code)
+		w.Write(rec.Body.Bytes())
+		if rec.Code == http.StatusOK {
+			fmt.Fprintln(w, "4.2")
+		}
+	}
+}
+
+// QuotaExhausted answers as random.org does once the quota has gone negative.
+func QuotaExhausted() Behaviour {
+	return func(w http.ResponseWriter, r *http.Request, _ http.HandlerFunc) {
+		writeError(w, "Error: You have used your quota of random bits for today. See the quota page for details.")
+	}
+}
+
+// Server is a Handler listening on a local address, see httptest.Server.
+type Server struct {
+	*httptest.Server
+	*Handler
+}
+
+// NewServer starts a fake random.org. Point randomorg.WithBaseURL at its URL
+// and Close it when done.
+func NewServer(opts ...Option) *Server {
+	h := NewHandler(opts...)
+	return &Server{
+		Server:  httptest.NewServer(h),
+		Handler: h,
+	}
+}
@@ -0,0 +1,165 @@
+package randomorgtest
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/koenno/standard-deviation-service/client"
+	"github.com/koenno/standard-deviation-service/client/randomorg"
+	"github.com/koenno/standard-deviation-service/random"
+	"github.com/stretchr/testify/assert"
+)
+
+func startServer(t *testing.T, opts ...Option) *Server {
+	t.Helper()
+	srv := NewServer(opts...)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newRandom(srv *Server, opts ...client.ClientOption) random.Random {
+	return random.NewRandom(
+		client.New(nil, opts...),
+		randomorg.NewBodyParser(),
+		randomorg.NewRequestFactory(randomorg.WithBaseURL(srv.URL)),
+	)
+}
+
+func TestShouldServeIntegersThroughWholeClient(t *testing.T) {
+	// given
+	srv := startServer(t, WithSeed(1))
+	sut := newRandom(srv)
+
+	// when
+	ints, err := sut.Integers(context.Background(), 5, 1, 6)
+
+	// then
+	assert.NoError(t, err)
+	assert.Len(t, ints, 5)
+	for _, i := range ints {
+		assert.True(t, i >= 1 && i <= 6, "integer %d out of range", i)
+	}
+	assert.Equal(t, 1, srv.Requests())
+	assert.Equal(t, int64(DefaultQuota-5*3), srv.BitsLeft())
+}
+
+func TestShouldDrawReproducibleIntegersWithSeed(t *testing.T) {
+	// given
+	first := newRandom(startServer(t, WithSeed(7)))
+	second := newRandom(startServer(t, WithSeed(7)))
+
+	// when
+	expected, firstErr := first.Integers(context.Background(), 10, 1, 100)
+	actual, secondErr := second.Integers(context.Background(), 10, 1, 100)
+
+	// then
+	assert.NoError(t, firstErr)
+	assert.NoError(t, secondErr)
+	assert.Equal(t, expected, actual)
+}
+
+func TestShouldFailAsScripted(t *testing.T) {
+	tests := []struct {
+		name      string
+		behaviour Behaviour
+		expected  error
+	}{
+		{name: "unavailable", behaviour: Unavailable(), expected: client.ErrResponse},
+		{name: "html error page", behaviour: HTMLErrorPage(http.StatusBadGateway), expected: client.ErrResponse},
+		{name: "html page", behaviour: HTMLPage(), expected: random.ErrItems},
+		{name: "malformed lines", behaviour: MalformedLines(), expected: random.ErrItems},
+		{name: "quota exhausted", behaviour: QuotaExhausted(), expected: client.ErrResponse},
+		{name: "latency beyond timeout", behaviour: Latency(time.Second), expected: client.ErrSendRequest},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// given
+			srv := startServer(t)
+			sut := newRandom(srv, client.WithTimeout(50*time.Millisecond))
+			srv.Enqueue(test.behaviour)
+
+			// when
+			ints, err := sut.Integers(context.Background(), 5, 1, 6)
+			recovered, recoveredErr := sut.Integers(context.Background(), 5, 1, 6)
+
+			// then
+			assert.ErrorIs(t, err, test.expected)
+			assert.Zero(t, ints)
+			assert.NoError(t, recoveredErr)
+			assert.Len(t, recovered, 5)
+		})
+	}
+}
+
+func TestShouldRetryUntilScriptedFailuresPass(t *testing.T) {
+	// given
+	srv := startServer(t)
+	sut := newRandom(srv, client.WithRetryPolicy(client.RetryPolicy{
+		MaxAttempts: 3,
+		BaseBackoff: time.Millisecond,
+		MaxBackoff:  time.Millisecond,
+	}))
+	srv.Enqueue(Unavailable(), HTMLErrorPage(http.StatusBadGateway))
+
+	// when
+	ints, err := sut.Integers(context.Background(), 5, 1, 6)
+
+	// then
+	assert.NoError(t, err)
+	assert.Len(t, ints, 5)
+	assert.Equal(t, 3, srv.Requests())
+}
+
+func TestShouldAnswerEveryRequestWithDefaultBehaviour(t *testing.T) {
+	// given
+	srv := startServer(t)
+	sut := newRandom(srv)
+	srv.SetDefault(Unavailable())
+
+	// when
+	_, firstErr := sut.Integers(context.Background(), 5, 1, 6)
+	_, secondErr := sut.Integers(context.Background(), 5, 1, 6)
+
+	// then
+	assert.ErrorIs(t, firstErr, client.ErrResponse)
+	assert.ErrorIs(t, secondErr, client.ErrResponse)
+}
+
+func TestShouldRefuseRequestsOnceQuotaIsNegative(t *testing.T) {
+	// given
+	srv := startServer(t, WithQuota(10))
+	httpClient := client.New(nil)
+	sut := random.NewRandom(httpClient, randomorg.NewBodyParser(), randomorg.NewRequestFactory(randomorg.WithBaseURL(srv.URL)))
+	quota := randomorg.NewQuotaTracker(httpClient, randomorg.WithBaseURL(srv.URL))
+
+	// when
+	_, overdrawErr := sut.Integers(context.Background(), 5, 1, 10)
+	_, refusedErr := sut.Integers(context.Background(), 5, 1, 10)
+	refreshErr := quota.Refresh(context.Background())
+
+	// then
+	assert.NoError(t, overdrawErr)
+	assert.ErrorIs(t, refusedErr, client.ErrResponse)
+	assert.ErrorContains(t, refusedErr, "quota")
+	assert.NoError(t, refreshErr)
+	bitsLeft, known := quota.BitsLeft()
+	assert.True(t, known)
+	assert.Equal(t, int64(10-5*4), bitsLeft)
+	assert.ErrorIs(t, quota.Ready(), randomorg.ErrQuotaExceeded)
+}
+
+func TestShouldRejectInvalidQuery(t *testing.T) {
+	// given
+	srv := startServer(t)
+
+	// when
+	resp, err := http.Get(srv.URL + "/integers/?num=0&min=1&max=6&col=1&base=10&format=plain&rnd=new")
+
+	// then
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, int64(DefaultQuota), srv.BitsLeft())
+}
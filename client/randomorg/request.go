@@ -11,16 +11,19 @@ import (
 )
 
 type RequestFactory struct {
+	options options
 }
 
-func NewRequestFactory() RequestFactory {
-	return RequestFactory{}
+func NewRequestFactory(opts ...Option) RequestFactory {
+	return RequestFactory{
+		options: newOptions(opts...),
+	}
 }
 
 func (f RequestFactory) NewRequest(ctx context.Context, opts ...client.Option) (*http.Request, error) {
-	cfg := client.NewOptions(opts...)
+	cfg := f.options.requestOptions(opts)
 
-	rawURL := "https://www.random.org/integers/?num=5&min=1&max=100&col=1&base=10&format=plain&rnd=new"
+	rawURL := f.options.baseURL + "/integers/?num=5&min=1&max=100&col=1&base=10&format=plain&rnd=new"
 	URL, err := url.Parse(rawURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse url: %v", err)
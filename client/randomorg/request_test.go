@@ -62,3 +62,22 @@ func TestShouldReturnProperURLWithCustomValues(t *testing.T) {
 	assert.Equal(t, "plain", query.Get("format"))
 	assert.Equal(t, "new", query.Get("rnd"))
 }
+
+func TestShouldReturnURLOfConfiguredHostWithConfiguredDefaults(t *testing.T) {
+	// given
+	sut := NewRequestFactory(WithBaseURL("http://127.0.0.1:8081"), WithDefaults(client.WithMin(0), client.WithMax(99)))
+
+	// when
+	req, err := sut.NewRequest(context.Background(), client.WithQuantity(7), client.WithMax(50))
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, "http", req.URL.Scheme)
+	assert.Equal(t, "127.0.0.1:8081", req.URL.Host)
+	assert.Equal(t, "/integers/", req.URL.Path)
+	query, err := url.ParseQuery(req.URL.RawQuery)
+	assert.NoError(t, err)
+	assert.Equal(t, "7", query.Get("num"))
+	assert.Equal(t, "0", query.Get("min"))
+	assert.Equal(t, "50", query.Get("max"))
+}
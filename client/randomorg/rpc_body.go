@@ -0,0 +1,96 @@
+package randomorg
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// RPCError is an error object returned in a JSON-RPC response envelope.
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("json-rpc error %d: %s", e.Code, e.Message)
+}
+
+type rpcResponse struct {
+	Result *rpcResult `json:"result"`
+	Error  *RPCError  `json:"error"`
+}
+
+type rpcResult struct {
+	Random struct {
+		Data []int `json:"data"`
+	} `json:"random"`
+	BitsUsed     int64 `json:"bitsUsed"`
+	BitsLeft     int64 `json:"bitsLeft"`
+	RequestsLeft int64 `json:"requestsLeft"`
+}
+
+// Usage reports the API key allowance as of the most recent response.
+type Usage struct {
+	BitsLeft     int64 `json:"bits_left"`
+	RequestsLeft int64 `json:"requests_left"`
+}
+
+// RPCBodyParser parses api.random.org JSON-RPC responses.
+type RPCBodyParser struct {
+	bitsLeft     *atomic.Int64
+	requestsLeft *atomic.Int64
+}
+
+func NewRPCBodyParser() RPCBodyParser {
+	p := RPCBodyParser{
+		bitsLeft:     &atomic.Int64{},
+		requestsLeft: &atomic.Int64{},
+	}
+	p.bitsLeft.Store(-1)
+	p.requestsLeft.Store(-1)
+	return p
+}
+
+func (p RPCBodyParser) ParseIntegers(bb []byte, contentType string) ([]int, error) {
+	if !jsonContentType(contentType) {
+		return nil, fmt.Errorf("unsupported content type: %s", contentType)
+	}
+
+	var resp rpcResponse
+	err := json.Unmarshal(bb, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode json-rpc response: %v", err)
+	}
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+	if resp.Result == nil {
+		return nil, fmt.Errorf("json-rpc response has neither result nor error")
+	}
+
+	p.bitsLeft.Store(resp.Result.BitsLeft)
+	p.requestsLeft.Store(resp.Result.RequestsLeft)
+
+	return resp.Result.Random.Data, nil
+}
+
+// Usage returns the allowance reported by the latest successful response.
+// Both values are -1 until the first response is parsed.
+func (p RPCBodyParser) Usage() Usage {
+	return Usage{
+		BitsLeft:     p.bitsLeft.Load(),
+		RequestsLeft: p.requestsLeft.Load(),
+	}
+}
+
+// Status reports the Usage.
+func (p RPCBodyParser) Status() any {
+	return p.Usage()
+}
+
+func jsonContentType(contentType string) bool {
+	elems := strings.Split(contentType, ";")
+	return strings.TrimSpace(elems[0]) == "application/json"
+}
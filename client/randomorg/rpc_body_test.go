@@ -0,0 +1,108 @@
+package randomorg
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShouldParseJSONRPCResult(t *testing.T) {
+	// given
+	sut := NewRPCBodyParser()
+	body := []byte(`{"jsonrpc":"2.0","result":{"random":{"data":[1,5,-4],"completionTime":"2011-10-10 13:19:12Z"},"bitsUsed":16,"bitsLeft":199984,"requestsLeft":9999,"advisoryDelay":0},"id":42}`)
+
+	// when
+	integers, err := sut.ParseIntegers(body, "application/json; charset=utf-8")
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 5, -4}, integers)
+	assert.Equal(t, Usage{BitsLeft: 199984, RequestsLeft: 9999}, sut.Usage())
+}
+
+func TestShouldReturnUnknownUsageBeforeFirstResult(t *testing.T) {
+	// given
+	sut := NewRPCBodyParser()
+
+	// when
+	usage := sut.Usage()
+
+	// then
+	assert.Equal(t, Usage{BitsLeft: -1, RequestsLeft: -1}, usage)
+}
+
+func TestShouldReportUsageAsStatus(t *testing.T) {
+	// given
+	sut := NewRPCBodyParser()
+	_, err := sut.ParseIntegers([]byte(`{"jsonrpc":"2.0","result":{"random":{"data":[3]},"bitsLeft":1200,"requestsLeft":7},"id":1}`), "application/json")
+	assert.NoError(t, err)
+
+	// when
+	status, err := json.Marshal(sut.Status())
+
+	// then
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"bits_left":1200,"requests_left":7}`, string(status))
+}
+
+func TestShouldReturnErrorWhenJSONRPCResponseIsNotValid(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        []byte
+		contentType string
+		expectedErr string
+	}{
+		{
+			name:        "error envelope",
+			body:        []byte(`{"jsonrpc":"2.0","error":{"code":401,"message":"The API key you specified does not exist","data":null},"id":42}`),
+			contentType: "application/json",
+			expectedErr: "json-rpc error 401: The API key you specified does not exist",
+		},
+		{
+			name:        "unsupported content type",
+			body:        []byte(`1`),
+			contentType: "text/plain",
+			expectedErr: "unsupported content type: text/plain",
+		},
+		{
+			name:        "malformed json",
+			body:        []byte(`{"jsonrpc":`),
+			contentType: "application/json",
+			expectedErr: "failed to decode json-rpc response: unexpected end of JSON input",
+		},
+		{
+			name:        "empty envelope",
+			body:        []byte(`{"jsonrpc":"2.0","id":42}`),
+			contentType: "application/json",
+			expectedErr: "json-rpc response has neither result nor error",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// given
+			sut := NewRPCBodyParser()
+
+			// when
+			integers, err := sut.ParseIntegers(test.body, test.contentType)
+
+			// then
+			assert.EqualError(t, err, test.expectedErr)
+			assert.Nil(t, integers)
+		})
+	}
+}
+
+func TestShouldExposeJSONRPCErrorCode(t *testing.T) {
+	// given
+	sut := NewRPCBodyParser()
+	body := []byte(`{"jsonrpc":"2.0","error":{"code":402,"message":"quota exceeded"},"id":1}`)
+
+	// when
+	_, err := sut.ParseIntegers(body, "application/json")
+
+	// then
+	var rpcErr *RPCError
+	assert.ErrorAs(t, err, &rpcErr)
+	assert.Equal(t, 402, rpcErr.Code)
+}
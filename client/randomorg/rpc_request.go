@@ -0,0 +1,83 @@
+package randomorg
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/koenno/standard-deviation-service/client"
+)
+
+const (
+	methodGenerateIntegers   = "generateIntegers"
+	methodGenerateSignedInts = "generateSignedIntegers"
+)
+
+type rpcRequest struct {
+	JSONRPC string    `json:"jsonrpc"`
+	Method  string    `json:"method"`
+	Params  rpcParams `json:"params"`
+	ID      uint64    `json:"id"`
+}
+
+type rpcParams struct {
+	APIKey      string `json:"apiKey"`
+	N           int    `json:"n"`
+	Min         int    `json:"min"`
+	Max         int    `json:"max"`
+	Replacement bool   `json:"replacement"`
+}
+
+// RPCRequestFactory builds requests for the api.random.org JSON-RPC API.
+type RPCRequestFactory struct {
+	options options
+	apiKey  string
+	method  string
+	nextID  *atomic.Uint64
+}
+
+// NewRPCRequestFactory returns a factory calling generateIntegers, or
+// generateSignedIntegers when signed is set.
+func NewRPCRequestFactory(apiKey string, signed bool, opts ...Option) RPCRequestFactory {
+	method := methodGenerateIntegers
+	if signed {
+		method = methodGenerateSignedInts
+	}
+	return RPCRequestFactory{
+		options: newOptions(opts...),
+		apiKey:  apiKey,
+		method:  method,
+		nextID:  &atomic.Uint64{},
+	}
+}
+
+func (f RPCRequestFactory) NewRequest(ctx context.Context, opts ...client.Option) (*http.Request, error) {
+	cfg := f.options.requestOptions(opts)
+
+	body, err := json.Marshal(rpcRequest{
+		JSONRPC: "2.0",
+		Method:  f.method,
+		Params: rpcParams{
+			APIKey:      f.apiKey,
+			N:           cfg.Quantity,
+			Min:         cfg.Min,
+			Max:         cfg.Max,
+			Replacement: true,
+		},
+		ID: f.nextID.Add(1),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request body: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.options.rpcURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	return req, nil
+}
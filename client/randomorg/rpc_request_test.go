@@ -0,0 +1,86 @@
+package randomorg
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/koenno/standard-deviation-service/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShouldReturnProperJSONRPCRequest(t *testing.T) {
+	tests := []struct {
+		name           string
+		signed         bool
+		expectedMethod string
+	}{
+		{
+			name:           "unsigned",
+			signed:         false,
+			expectedMethod: "generateIntegers",
+		},
+		{
+			name:           "signed",
+			signed:         true,
+			expectedMethod: "generateSignedIntegers",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// given
+			sut := NewRPCRequestFactory("secret", test.signed)
+
+			// when
+			req, err := sut.NewRequest(context.Background(),
+				client.WithQuantity(23), client.WithMin(-11), client.WithMax(435))
+
+			// then
+			assert.NoError(t, err)
+			assert.Equal(t, http.MethodPost, req.Method)
+			assert.Equal(t, "https://api.random.org/json-rpc/4/invoke", req.URL.String())
+			assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+			var body rpcRequest
+			err = json.NewDecoder(req.Body).Decode(&body)
+			assert.NoError(t, err)
+			assert.Equal(t, "2.0", body.JSONRPC)
+			assert.Equal(t, test.expectedMethod, body.Method)
+			assert.Equal(t, rpcParams{APIKey: "secret", N: 23, Min: -11, Max: 435, Replacement: true}, body.Params)
+			assert.NotZero(t, body.ID)
+		})
+	}
+}
+
+func TestShouldUseDistinctJSONRPCRequestIDs(t *testing.T) {
+	// given
+	sut := NewRPCRequestFactory("secret", false)
+
+	// when
+	first, firstErr := sut.NewRequest(context.Background())
+	second, secondErr := sut.NewRequest(context.Background())
+
+	// then
+	assert.NoError(t, firstErr)
+	assert.NoError(t, secondErr)
+	var firstBody, secondBody rpcRequest
+	assert.NoError(t, json.NewDecoder(first.Body).Decode(&firstBody))
+	assert.NoError(t, json.NewDecoder(second.Body).Decode(&secondBody))
+	assert.NotEqual(t, firstBody.ID, secondBody.ID)
+}
+
+func TestShouldSendJSONRPCRequestToConfiguredURL(t *testing.T) {
+	// given
+	sut := NewRPCRequestFactory("secret", false, WithRPCURL("http://127.0.0.1:8081/json-rpc/4/invoke"), WithDefaults(client.WithQuantity(3)))
+
+	// when
+	req, err := sut.NewRequest(context.Background())
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, "http://127.0.0.1:8081/json-rpc/4/invoke", req.URL.String())
+	var body rpcRequest
+	err = json.NewDecoder(req.Body).Decode(&body)
+	assert.NoError(t, err)
+	assert.Equal(t, rpcParams{APIKey: "secret", N: 3, Min: 1, Max: 10, Replacement: true}, body.Params)
+}
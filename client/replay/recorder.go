@@ -0,0 +1,109 @@
+package replay
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/slog"
+)
+
+// Recorder is an http.RoundTripper appending every attempt it forwards,
+// together with the response, as a JSON line to a file a Replayer can serve
+// them from. Given to the client as its transport, it records each retry of a
+// request. Attempts abandoned by their caller are not recorded. A failure to
+// record is logged and does not affect the response.
+type Recorder struct {
+	transport http.RoundTripper
+	now       func() time.Time
+
+	mu  sync.Mutex
+	f   *os.File
+	enc *json.Encoder
+}
+
+// NewRecorder opens the recording at path, creating it if needed, and appends
+// to it the exchanges of requests sent through transport.
+func NewRecorder(transport http.RoundTripper, path string) (*Recorder, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the recording: %w", err)
+	}
+	enc := json.NewEncoder(f)
+	// keep URLs and HTML error pages readable
+	enc.SetEscapeHTML(false)
+	return &Recorder{
+		transport: transport,
+		now:       time.Now,
+		f:         f,
+		enc:       enc,
+	}, nil
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+	exchange := Exchange{
+		Timestamp:   r.now(),
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		RequestBody: normalize(body),
+	}
+
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		r.recordError(req, exchange, err)
+		return nil, err
+	}
+	payload, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		err = fmt.Errorf("unable to read body: %w", err)
+		r.recordError(req, exchange, err)
+		return nil, err
+	}
+	exchange.Status = resp.StatusCode
+	exchange.ContentType = resp.Header.Get("Content-Type")
+	exchange.RetryAfter = resp.Header.Get("Retry-After")
+	exchange.Body = string(payload)
+	r.record(exchange)
+	resp.Body = io.NopCloser(bytes.NewReader(payload))
+	return resp, nil
+}
+
+// recordError records the error of an attempt unless its caller abandoned it.
+func (r *Recorder) recordError(req *http.Request, exchange Exchange, err error) {
+	if req.Context().Err() != nil {
+		return
+	}
+	exchange.Error = err.Error()
+	r.record(exchange)
+}
+
+func (r *Recorder) record(exchange Exchange) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	err := r.enc.Encode(exchange)
+	if err != nil {
+		slog.Error("failed to record a random.org response", "timestamp", time.Now(), "url", exchange.URL, "error", err)
+	}
+}
+
+// Close flushes the recording to disk and closes the file.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	err := r.f.Sync()
+	if err != nil {
+		r.f.Close()
+		return fmt.Errorf("failed to flush the recording: %w", err)
+	}
+	return r.f.Close()
+}
@@ -0,0 +1,205 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/koenno/standard-deviation-service/client"
+	clientmocks "github.com/koenno/standard-deviation-service/client/mocks"
+	"github.com/koenno/standard-deviation-service/client/replay/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var epoch = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func newRecorder(t *testing.T, transport http.RoundTripper, path string) *Recorder {
+	t.Helper()
+	sut, err := NewRecorder(transport, path)
+	assert.NoError(t, err)
+	sut.now = func() time.Time { return epoch }
+	t.Cleanup(func() {
+		sut.Close()
+	})
+	return sut
+}
+
+func newResponse(status int, header http.Header, body string) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func readRecording(t *testing.T, path string) []Exchange {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	var exchanges []Exchange
+	for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+		var exchange Exchange
+		assert.NoError(t, json.Unmarshal([]byte(line), &exchange))
+		exchanges = append(exchanges, exchange)
+	}
+	return exchanges
+}
+
+func TestShouldRecordEveryOutcomeOfAttempt(t *testing.T) {
+	tests := []struct {
+		name     string
+		resp     *http.Response
+		err      error
+		expected Exchange
+	}{
+		{
+			name:     "response",
+			resp:     newResponse(http.StatusOK, http.Header{"Content-Type": {"text/plain;charset=UTF-8"}}, "4\n2\n"),
+			expected: Exchange{Status: http.StatusOK, ContentType: "text/plain;charset=UTF-8", Body: "4\n2\n"},
+		},
+		{
+			name:     "unexpected status",
+			resp:     newResponse(http.StatusServiceUnavailable, http.Header{"Content-Type": {"text/html"}, "Retry-After": {"2"}}, "<html>maintenance</html>"),
+			expected: Exchange{Status: http.StatusServiceUnavailable, ContentType: "text/html", RetryAfter: "2", Body: "<html>maintenance</html>"},
+		},
+		{
+			name:     "failure to send",
+			err:      errors.New("connection refused"),
+			expected: Exchange{Error: "connection refused"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// given
+			path := filepath.Join(t.TempDir(), "recording.jsonl")
+			transportMock := mocks.NewRoundTripper(t)
+			sut := newRecorder(t, transportMock, path)
+			req, _ := http.NewRequest(http.MethodGet, "https://www.random.org/integers/?num=2", nil)
+
+			transportMock.EXPECT().RoundTrip(req).Return(test.resp, test.err).Once()
+
+			// when
+			resp, err := sut.RoundTrip(req)
+
+			// then
+			assert.Equal(t, test.err, err)
+			if test.resp != nil {
+				payload, readErr := io.ReadAll(resp.Body)
+				assert.NoError(t, readErr)
+				assert.Equal(t, test.expected.Body, string(payload))
+				assert.Equal(t, test.resp.StatusCode, resp.StatusCode)
+			}
+			expected := test.expected
+			expected.Timestamp = epoch
+			expected.Method = http.MethodGet
+			expected.URL = "https://www.random.org/integers/?num=2"
+			assert.Equal(t, []Exchange{expected}, readRecording(t, path))
+		})
+	}
+}
+
+func TestShouldRecordEveryAttemptOfRetriedRequest(t *testing.T) {
+	// given
+	path := filepath.Join(t.TempDir(), "recording.jsonl")
+	transportMock := mocks.NewRoundTripper(t)
+	limiterMock := clientmocks.NewRateLimiter(t)
+	recorder := newRecorder(t, transportMock, path)
+	sut := client.New(limiterMock,
+		client.WithRetryPolicy(client.RetryPolicy{MaxAttempts: 3, BaseBackoff: time.Millisecond, MaxBackoff: time.Millisecond}),
+		client.WithTransport(recorder),
+	)
+	req, _ := http.NewRequest(http.MethodGet, "https://www.random.org/integers/?num=2", nil)
+
+	limiterMock.EXPECT().Wait(mock.Anything).Return(nil).Times(2)
+	transportMock.EXPECT().RoundTrip(mock.Anything).Return(newResponse(http.StatusServiceUnavailable, http.Header{"Content-Type": {"text/html"}}, "busy"), nil).Once()
+	transportMock.EXPECT().RoundTrip(mock.Anything).Return(newResponse(http.StatusOK, http.Header{"Content-Type": {"text/plain"}}, "4\n2\n"), nil).Once()
+
+	// when
+	payload, _, err := sut.Send(req)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, "4\n2\n", string(payload))
+	recorded := readRecording(t, path)
+	if assert.Len(t, recorded, 2) {
+		assert.Equal(t, http.StatusServiceUnavailable, recorded[0].Status)
+		assert.Equal(t, http.StatusOK, recorded[1].Status)
+	}
+}
+
+func TestShouldRecordRequestBodyWithoutIDAndAPIKey(t *testing.T) {
+	// given
+	path := filepath.Join(t.TempDir(), "recording.jsonl")
+	transportMock := mocks.NewRoundTripper(t)
+	sut := newRecorder(t, transportMock, path)
+	body := `{"jsonrpc":"2.0","method":"generateIntegers","params":{"apiKey":"secret","n":2,"min":1,"max":10},"id":7}`
+	req, _ := http.NewRequest(http.MethodPost, "https://api.random.org/json-rpc/4/invoke", bytes.NewReader([]byte(body)))
+
+	transportMock.EXPECT().RoundTrip(req).RunAndReturn(func(req *http.Request) (*http.Response, error) {
+		sent, err := io.ReadAll(req.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, body, string(sent))
+		return newResponse(http.StatusOK, http.Header{"Content-Type": {"application/json"}}, `{"jsonrpc":"2.0","result":{},"id":7}`), nil
+	}).Once()
+
+	// when
+	_, err := sut.RoundTrip(req)
+
+	// then
+	assert.NoError(t, err)
+	recorded := readRecording(t, path)
+	if assert.Len(t, recorded, 1) {
+		assert.JSONEq(t, `{"jsonrpc":"2.0","method":"generateIntegers","params":{"n":2,"min":1,"max":10}}`, recorded[0].RequestBody)
+	}
+}
+
+func TestShouldNotRecordAbandonedAttempt(t *testing.T) {
+	// given
+	path := filepath.Join(t.TempDir(), "recording.jsonl")
+	transportMock := mocks.NewRoundTripper(t)
+	sut := newRecorder(t, transportMock, path)
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://www.random.org/integers/?num=2", nil)
+
+	transportMock.EXPECT().RoundTrip(mock.Anything).RunAndReturn(func(req *http.Request) (*http.Response, error) {
+		cancel()
+		return nil, context.Canceled
+	}).Once()
+
+	// when
+	_, err := sut.RoundTrip(req)
+
+	// then
+	assert.ErrorIs(t, err, context.Canceled)
+	content, readErr := os.ReadFile(path)
+	assert.NoError(t, readErr)
+	assert.Empty(t, content)
+}
+
+func TestShouldAppendToExistingRecording(t *testing.T) {
+	// given
+	path := filepath.Join(t.TempDir(), "recording.jsonl")
+	transportMock := mocks.NewRoundTripper(t)
+	first := newRecorder(t, transportMock, path)
+	req, _ := http.NewRequest(http.MethodGet, "https://www.random.org/integers/?num=2", nil)
+
+	transportMock.EXPECT().RoundTrip(req).Return(nil, errors.New("failure")).Twice()
+	first.RoundTrip(req)
+	assert.NoError(t, first.Close())
+
+	// when
+	sut := newRecorder(t, transportMock, path)
+	sut.RoundTrip(req)
+
+	// then
+	assert.Len(t, readRecording(t, path), 2)
+}
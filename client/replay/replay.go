@@ -0,0 +1,114 @@
+package replay
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotRecorded = errors.New("no recorded response")
+	ErrCorrupt     = errors.New("corrupt recording")
+)
+
+//go:generate mockery --srcpkg net/http --name=RoundTripper --case underscore --with-expecter
+
+// Exchange is a single line of a recording: an attempt to send a request to
+// random.org and either the response to it or the error it failed with. The
+// JSON-RPC id and api key are left out of the request body.
+type Exchange struct {
+	Timestamp   time.Time `json:"timestamp"`
+	Method      string    `json:"method"`
+	URL         string    `json:"url"`
+	RequestBody string    `json:"request_body,omitempty"`
+	Status      int       `json:"status,omitempty"`
+	ContentType string    `json:"content_type,omitempty"`
+	RetryAfter  string    `json:"retry_after,omitempty"`
+	Body        string    `json:"body,omitempty"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// response rebuilds the response, or the error, the transport answered req
+// with when the exchange was recorded.
+func (e Exchange) response(req *http.Request) (*http.Response, error) {
+	if e.Error != "" {
+		return nil, errors.New(e.Error)
+	}
+	header := make(http.Header)
+	if e.ContentType != "" {
+		header.Set("Content-Type", e.ContentType)
+	}
+	if e.RetryAfter != "" {
+		header.Set("Retry-After", e.RetryAfter)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.Status, http.StatusText(e.Status)),
+		StatusCode:    e.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}, nil
+}
+
+// requestKey identifies the requests answered alike. It leaves out the scheme
+// and host so that a recording can be replayed against another random.org URL,
+// and sorts the query parameters.
+type requestKey struct {
+	method string
+	path   string
+	query  string
+	body   string
+}
+
+func keyOf(method string, u *url.URL, body string) requestKey {
+	return requestKey{method: method, path: u.EscapedPath(), query: u.Query().Encode(), body: body}
+}
+
+// readBody returns the body of the request without consuming it.
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("unable to read request body: %w", err)
+		}
+		defer body.Close()
+		return io.ReadAll(body)
+	}
+	payload, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read request body: %w", err)
+	}
+	req.Body = io.NopCloser(bytes.NewReader(payload))
+	return payload, nil
+}
+
+// normalize drops the fields of a JSON-RPC request that differ between runs
+// of the same request: the id and the api key.
+func normalize(body []byte) string {
+	var rpc map[string]any
+	if err := json.Unmarshal(body, &rpc); err != nil {
+		return string(body)
+	}
+	delete(rpc, "id")
+	if params, ok := rpc["params"].(map[string]any); ok {
+		delete(params, "apiKey")
+	}
+	normalized, err := json.Marshal(rpc)
+	if err != nil {
+		return string(body)
+	}
+	return string(normalized)
+}
@@ -0,0 +1,88 @@
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+)
+
+// Replayer is an http.RoundTripper answering requests with the responses
+// recorded by a Recorder instead of sending them. Given to the client as its
+// transport, it answers each retry of a request with the next recorded
+// attempt. Requests match a recorded exchange by method, path, query and body,
+// ignoring the scheme and host of the URL and the JSON-RPC id and api key.
+// Identical requests are answered in the order they were recorded and, once
+// those run out, with the last of them again. Requests never recorded fail with ErrNotRecorded.
+type Replayer struct {
+	exchanges map[requestKey][]Exchange
+
+	mu     sync.Mutex
+	served map[requestKey]int
+}
+
+// NewReplayer loads the recording at path.
+func NewReplayer(path string) (*Replayer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the recording: %w", err)
+	}
+	defer f.Close()
+
+	r := &Replayer{
+		exchanges: make(map[requestKey][]Exchange),
+		served:    make(map[requestKey]int),
+	}
+	reader := bufio.NewReader(f)
+	for n := 1; ; n++ {
+		line, err := reader.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("failed to read the recording: %w", err)
+		}
+		if len(bytes.TrimSpace(line)) > 0 {
+			var exchange Exchange
+			decodeErr := json.Unmarshal(line, &exchange)
+			if decodeErr != nil {
+				return nil, fmt.Errorf("%w: line %d: %w", ErrCorrupt, n, decodeErr)
+			}
+			u, parseErr := url.Parse(exchange.URL)
+			if parseErr != nil {
+				return nil, fmt.Errorf("%w: line %d: %w", ErrCorrupt, n, parseErr)
+			}
+			key := keyOf(exchange.Method, u, exchange.RequestBody)
+			r.exchanges[key] = append(r.exchanges[key], exchange)
+		}
+		if errors.Is(err, io.EOF) {
+			return r, nil
+		}
+	}
+}
+
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+	key := keyOf(req.Method, req.URL, normalize(body))
+	exchanges, ok := r.exchanges[key]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s %s", ErrNotRecorded, req.Method, req.URL)
+	}
+
+	r.mu.Lock()
+	i := r.served[key]
+	if i < len(exchanges)-1 {
+		r.served[key]++
+	}
+	r.mu.Unlock()
+	return exchanges[i].response(req)
+}
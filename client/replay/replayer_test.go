@@ -0,0 +1,159 @@
+package replay
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/koenno/standard-deviation-service/client"
+	clientmocks "github.com/koenno/standard-deviation-service/client/mocks"
+	"github.com/koenno/standard-deviation-service/client/randomorg"
+	"github.com/koenno/standard-deviation-service/client/replay/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func writeRecording(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "recording.jsonl")
+	err := os.WriteFile(path, []byte(content), 0o644)
+	assert.NoError(t, err)
+	return path
+}
+
+func newReplayer(t *testing.T, path string) *Replayer {
+	t.Helper()
+	sut, err := NewReplayer(path)
+	assert.NoError(t, err)
+	return sut
+}
+
+// newReplayingClient sends requests through a client replaying the recording
+// at path.
+func newReplayingClient(t *testing.T, path string) client.Client {
+	t.Helper()
+	limiterMock := clientmocks.NewRateLimiter(t)
+	limiterMock.EXPECT().Wait(mock.Anything).Return(nil).Maybe()
+	return client.New(limiterMock, client.WithTransport(newReplayer(t, path)))
+}
+
+func readResponse(t *testing.T, resp *http.Response) string {
+	t.Helper()
+	defer resp.Body.Close()
+	payload, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	return string(payload)
+}
+
+func TestShouldReplayIdenticalRequestsInRecordedOrder(t *testing.T) {
+	// given
+	path := writeRecording(t, `{"method":"GET","url":"https://www.random.org/integers/?num=2","status":200,"content_type":"text/plain","body":"1\n2\n"}
+{"method":"GET","url":"https://www.random.org/integers/?num=3","status":200,"content_type":"text/plain","body":"7\n7\n7\n"}
+{"method":"GET","url":"https://www.random.org/integers/?num=2","status":200,"content_type":"text/plain","body":"3\n4\n"}
+`)
+	sut := newReplayer(t, path)
+	req, _ := http.NewRequest(http.MethodGet, "https://www.random.org/integers/?num=2", nil)
+
+	// when
+	var bodies []string
+	for i := 0; i < 3; i++ {
+		resp, err := sut.RoundTrip(req)
+		assert.NoError(t, err)
+		assert.Equal(t, "text/plain", resp.Header.Get("Content-Type"))
+		bodies = append(bodies, readResponse(t, resp))
+	}
+
+	// then
+	assert.Equal(t, []string{"1\n2\n", "3\n4\n", "3\n4\n"}, bodies)
+}
+
+func TestShouldReplayRPCRequestsRegardlessOfIDAndAPIKey(t *testing.T) {
+	// given
+	path := filepath.Join(t.TempDir(), "recording.jsonl")
+	transportMock := mocks.NewRoundTripper(t)
+	recorder := newRecorder(t, transportMock, path)
+	rpcResponse := `{"jsonrpc":"2.0","result":{"random":{"data":[4,2]}},"id":1}`
+	transportMock.EXPECT().RoundTrip(mock.Anything).Return(newResponse(http.StatusOK, http.Header{"Content-Type": {"application/json"}}, rpcResponse), nil).Once()
+	recordedReq, _ := randomorg.NewRPCRequestFactory("production-key", false).NewRequest(context.Background(), client.WithQuantity(2))
+	_, err := recorder.RoundTrip(recordedReq)
+	assert.NoError(t, err)
+	assert.NoError(t, recorder.Close())
+
+	factory := randomorg.NewRPCRequestFactory("local-key", false)
+	// the replayed request gets a different id than the recorded one
+	factory.NewRequest(context.Background())
+	req, _ := factory.NewRequest(context.Background(), client.WithQuantity(2))
+	sut := newReplayer(t, path)
+
+	// when
+	resp, err := sut.RoundTrip(req)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, rpcResponse, readResponse(t, resp))
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+}
+
+func TestShouldReplayRecordedFailures(t *testing.T) {
+	// given
+	path := writeRecording(t, `{"method":"GET","url":"https://www.random.org/integers/?num=1","status":503,"content_type":"text/html","body":"<html>maintenance</html>"}
+{"method":"GET","url":"https://www.random.org/integers/?num=2","error":"connection refused"}
+`)
+	sut := newReplayingClient(t, path)
+	statusReq, _ := http.NewRequest(http.MethodGet, "https://www.random.org/integers/?num=1", nil)
+	sendReq, _ := http.NewRequest(http.MethodGet, "https://www.random.org/integers/?num=2", nil)
+
+	// when
+	_, _, statusErr := sut.Send(statusReq)
+	_, _, sendErr := sut.Send(sendReq)
+
+	// then
+	assert.ErrorIs(t, statusErr, client.ErrResponse)
+	assert.EqualError(t, statusErr, "response failure: status code 503; body <html>maintenance</html>")
+	assert.ErrorIs(t, sendErr, client.ErrSendRequest)
+	assert.ErrorContains(t, sendErr, "connection refused")
+}
+
+func TestShouldFailForRequestNotRecorded(t *testing.T) {
+	// given
+	path := writeRecording(t, `{"method":"GET","url":"https://www.random.org/integers/?num=1","status":200,"body":"5\n"}`)
+	sut := newReplayingClient(t, path)
+	req, _ := http.NewRequest(http.MethodGet, "https://www.random.org/integers/?num=2", nil)
+
+	// when
+	_, _, err := sut.Send(req)
+
+	// then
+	assert.ErrorIs(t, err, ErrNotRecorded)
+	assert.ErrorIs(t, err, client.ErrSendRequest)
+}
+
+func TestShouldRefuseCorruptRecording(t *testing.T) {
+	// given
+	path := writeRecording(t, "{\"method\":\"GET\"}\nnot json\n")
+
+	// when
+	_, err := NewReplayer(path)
+
+	// then
+	assert.ErrorIs(t, err, ErrCorrupt)
+	assert.ErrorContains(t, err, "line 2")
+}
+
+func TestShouldReplayRecordingAgainstAnotherHost(t *testing.T) {
+	// given
+	path := writeRecording(t, `{"method":"GET","url":"https://www.random.org/integers/?num=2&min=1","status":200,"content_type":"text/plain","body":"1\n2\n"}
+`)
+	sut := newReplayer(t, path)
+	req, _ := http.NewRequest(http.MethodGet, "http://127.0.0.1:8089/integers/?min=1&num=2", nil)
+
+	// when
+	resp, err := sut.RoundTrip(req)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, "1\n2\n", readResponse(t, resp))
+}
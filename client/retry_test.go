@@ -0,0 +1,312 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/koenno/standard-deviation-service/client/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func fastRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseBackoff: time.Millisecond,
+		MaxBackoff:  10 * time.Millisecond,
+		Jitter:      0.5,
+	}
+}
+
+func TestShouldRetryRetryableStatusCodes(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+	}{
+		{
+			name:       "too many requests",
+			statusCode: http.StatusTooManyRequests,
+		},
+		{
+			name:       "internal server error",
+			statusCode: http.StatusInternalServerError,
+		},
+		{
+			name:       "service unavailable",
+			statusCode: http.StatusServiceUnavailable,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// given
+			limiterMock := mocks.NewRateLimiter(t)
+			var calls atomic.Int32
+			fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if calls.Add(1) == 1 {
+					w.WriteHeader(test.statusCode)
+					return
+				}
+				w.Header().Add("content-type", "text/plain")
+				w.Write([]byte("4\n2\n"))
+			}))
+			defer fakeServer.Close()
+			req, _ := http.NewRequest(http.MethodGet, fakeServer.URL, nil)
+			sut := New(limiterMock, WithRetryPolicy(fastRetryPolicy()))
+
+			limiterMock.EXPECT().Wait(req.Context()).Return(nil).Twice()
+
+			// when
+			payload, contentType, err := sut.Send(req)
+
+			// then
+			assert.NoError(t, err)
+			assert.Equal(t, []byte("4\n2\n"), payload)
+			assert.Equal(t, "text/plain", contentType)
+			assert.Equal(t, int32(2), calls.Load())
+		})
+	}
+}
+
+func TestShouldNotRetryClientErrors(t *testing.T) {
+	// given
+	limiterMock := mocks.NewRateLimiter(t)
+	var calls atomic.Int32
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer fakeServer.Close()
+	req, _ := http.NewRequest(http.MethodGet, fakeServer.URL, nil)
+	sut := New(limiterMock, WithRetryPolicy(fastRetryPolicy()))
+
+	limiterMock.EXPECT().Wait(req.Context()).Return(nil).Once()
+
+	// when
+	_, _, err := sut.Send(req)
+
+	// then
+	assert.ErrorIs(t, err, ErrResponse)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestShouldGiveUpAfterMaxAttempts(t *testing.T) {
+	// given
+	limiterMock := mocks.NewRateLimiter(t)
+	var calls atomic.Int32
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer fakeServer.Close()
+	req, _ := http.NewRequest(http.MethodGet, fakeServer.URL, nil)
+	sut := New(limiterMock, WithRetryPolicy(fastRetryPolicy()))
+
+	limiterMock.EXPECT().Wait(req.Context()).Return(nil).Times(3)
+
+	// when
+	payload, contentType, err := sut.Send(req)
+
+	// then
+	assert.ErrorIs(t, err, ErrResponse)
+	assert.Zero(t, payload)
+	assert.Zero(t, contentType)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestShouldRetryNetworkErrors(t *testing.T) {
+	// given
+	limiterMock := mocks.NewRateLimiter(t)
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	URL := fakeServer.URL
+	fakeServer.Close()
+	req, _ := http.NewRequest(http.MethodGet, URL, nil)
+	sut := New(limiterMock, WithRetryPolicy(fastRetryPolicy()))
+
+	limiterMock.EXPECT().Wait(req.Context()).Return(nil).Times(3)
+
+	// when
+	_, _, err := sut.Send(req)
+
+	// then
+	assert.ErrorIs(t, err, ErrSendRequest)
+}
+
+func TestShouldHonorRetryAfterHeader(t *testing.T) {
+	// given
+	limiterMock := mocks.NewRateLimiter(t)
+	var calls atomic.Int32
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Add("content-type", "text/plain")
+	}))
+	defer fakeServer.Close()
+	req, _ := http.NewRequest(http.MethodGet, fakeServer.URL, nil)
+	policy := fastRetryPolicy()
+	policy.MaxBackoff = 2 * time.Second
+	sut := New(limiterMock, WithRetryPolicy(policy))
+
+	limiterMock.EXPECT().Wait(req.Context()).Return(nil).Twice()
+
+	// when
+	start := time.Now()
+	_, _, err := sut.Send(req)
+
+	// then
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), time.Second)
+}
+
+func TestShouldCapRetryAfterAtMaxBackoff(t *testing.T) {
+	// given
+	limiterMock := mocks.NewRateLimiter(t)
+	var calls atomic.Int32
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Add("content-type", "text/plain")
+	}))
+	defer fakeServer.Close()
+	req, _ := http.NewRequest(http.MethodGet, fakeServer.URL, nil)
+	sut := New(limiterMock, WithRetryPolicy(fastRetryPolicy()))
+
+	limiterMock.EXPECT().Wait(req.Context()).Return(nil).Twice()
+
+	// when
+	start := time.Now()
+	_, _, err := sut.Send(req)
+
+	// then
+	assert.NoError(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestShouldStopRetryingWhenBackoffExceedsDeadline(t *testing.T) {
+	// given
+	limiterMock := mocks.NewRateLimiter(t)
+	var calls atomic.Int32
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer fakeServer.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, fakeServer.URL, nil)
+	policy := fastRetryPolicy()
+	policy.MaxBackoff = time.Minute
+	sut := New(limiterMock, WithRetryPolicy(policy))
+
+	limiterMock.EXPECT().Wait(mock.Anything).Return(nil).Once()
+
+	// when
+	start := time.Now()
+	_, _, err := sut.Send(req)
+
+	// then
+	assert.ErrorIs(t, err, ErrResponse)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestShouldResendRequestBodyOnRetry(t *testing.T) {
+	// given
+	limiterMock := mocks.NewRateLimiter(t)
+	var calls atomic.Int32
+	var bodies []string
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Add("content-type", "application/json")
+	}))
+	defer fakeServer.Close()
+	req, _ := http.NewRequest(http.MethodPost, fakeServer.URL, strings.NewReader(`{"id":1}`))
+	sut := New(limiterMock, WithRetryPolicy(fastRetryPolicy()))
+
+	limiterMock.EXPECT().Wait(req.Context()).Return(nil).Twice()
+
+	// when
+	_, _, err := sut.Send(req)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, []string{`{"id":1}`, `{"id":1}`}, bodies)
+}
+
+func TestShouldParseRetryAfterHeader(t *testing.T) {
+	tests := []struct {
+		name          string
+		header        string
+		expected      time.Duration
+		expectedValid bool
+	}{
+		{
+			name:          "missing",
+			header:        "",
+			expected:      0,
+			expectedValid: false,
+		},
+		{
+			name:          "seconds",
+			header:        "7",
+			expected:      7 * time.Second,
+			expectedValid: true,
+		},
+		{
+			name:          "date in the past",
+			header:        "Wed, 21 Oct 2015 07:28:00 GMT",
+			expected:      0,
+			expectedValid: true,
+		},
+		{
+			name:          "garbage",
+			header:        "soon",
+			expected:      0,
+			expectedValid: false,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// when
+			delay, ok := retryAfter(test.header)
+
+			// then
+			assert.Equal(t, test.expected, delay)
+			assert.Equal(t, test.expectedValid, ok)
+		})
+	}
+}
+
+func TestShouldCapBackoff(t *testing.T) {
+	// given
+	policy := RetryPolicy{
+		MaxAttempts: 10,
+		BaseBackoff: 100 * time.Millisecond,
+		MaxBackoff:  time.Second,
+	}
+
+	// when
+	delays := []time.Duration{policy.backoff(1), policy.backoff(2), policy.backoff(3), policy.backoff(5)}
+
+	// then
+	assert.Equal(t, []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, time.Second}, delays)
+}
@@ -0,0 +1,79 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/koenno/standard-deviation-service/client/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func TestShouldTraceRateLimiterWaitAndOutboundCall(t *testing.T) {
+	// given
+	recorder := recordSpans(t)
+	limiterMock := mocks.NewRateLimiter(t)
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer fakeServer.Close()
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, fakeServer.URL, nil)
+	sut := New(limiterMock)
+
+	limiterMock.EXPECT().Wait(mock.Anything).Return(nil).Once()
+
+	// when
+	_, _, err := sut.Send(req)
+	parent.End()
+
+	// then
+	assert.ErrorIs(t, err, ErrResponse)
+	spans := recorder.Ended()
+	assert.Len(t, spans, 3)
+	wait, call := spans[0], spans[1]
+	assert.Equal(t, "client.RateLimiter.Wait", wait.Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), wait.Parent().SpanID())
+	assert.Equal(t, "HTTP GET", call.Name())
+	assert.Equal(t, trace.SpanKindClient, call.SpanKind())
+	assert.Equal(t, parent.SpanContext().SpanID(), call.Parent().SpanID())
+	assert.Contains(t, call.Attributes(), attribute.Int("http.response.status_code", http.StatusServiceUnavailable))
+	assert.Equal(t, codes.Error, call.Status().Code)
+}
+
+func TestShouldTraceFailedRateLimiterWait(t *testing.T) {
+	// given
+	recorder := recordSpans(t)
+	limiterMock := mocks.NewRateLimiter(t)
+	req, _ := http.NewRequest(http.MethodGet, "http://some.domain.com", nil)
+	sut := New(limiterMock)
+
+	limiterMock.EXPECT().Wait(mock.Anything).Return(errors.New("would exceed deadline")).Once()
+
+	// when
+	_, _, err := sut.Send(req)
+
+	// then
+	assert.ErrorIs(t, err, ErrRateLimit)
+	spans := recorder.Ended()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "client.RateLimiter.Wait", spans[0].Name())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+}
@@ -0,0 +1,53 @@
+// Command fakerandomorg serves a fake of the random.org /integers/ and /quota/
+// endpoints, so the service can be run locally with -random-org-url pointing at it.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/koenno/standard-deviation-service/client/randomorg/randomorgtest"
+	"golang.org/x/exp/slog"
+)
+
+func main() {
+	port := flag.Int("port", 8081, "port number")
+	quota := flag.Int64("quota", randomorgtest.DefaultQuota, "bits left at start")
+	seed := flag.Uint64("seed", 0, "seed of the drawn integers, 0 seeds them randomly")
+	latency := flag.Duration("latency", 0, "delay of every /integers/ response")
+	failure := flag.String("fail", "", "answer every /integers/ request with a failure: unavailable, html, malformed or quota")
+	flag.Parse()
+
+	opts := []randomorgtest.Option{
+		randomorgtest.WithQuota(*quota),
+		randomorgtest.WithLatency(*latency),
+	}
+	if *seed != 0 {
+		opts = append(opts, randomorgtest.WithSeed(*seed))
+	}
+	handler := randomorgtest.NewHandler(opts...)
+	failures := map[string]randomorgtest.Behaviour{
+		"unavailable": randomorgtest.Unavailable(),
+		"html":        randomorgtest.HTMLErrorPage(http.StatusBadGateway),
+		"malformed":   randomorgtest.MalformedLines(),
+		"quota":       randomorgtest.QuotaExhausted(),
+	}
+	if *failure != "" {
+		behaviour, ok := failures[*failure]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "unknown failure %q\n", *failure)
+			os.Exit(2)
+		}
+		handler.SetDefault(behaviour)
+	}
+
+	slog.Info("fake random.org is running", "timestamp", time.Now(), "port", *port)
+	err := http.ListenAndServe(fmt.Sprintf(":%d", *port), handler)
+	if err != nil {
+		slog.Error("fake random.org stopped with error", "timestamp", time.Now(), "error", err)
+		os.Exit(1)
+	}
+}
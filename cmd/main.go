@@ -1,42 +1,253 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
+	"fmt"
+	"math"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/koenno/standard-deviation-service/breaker"
 	"github.com/koenno/standard-deviation-service/client"
 	"github.com/koenno/standard-deviation-service/client/randomorg"
+	"github.com/koenno/standard-deviation-service/client/replay"
+	"github.com/koenno/standard-deviation-service/config"
+	"github.com/koenno/standard-deviation-service/metrics"
 	"github.com/koenno/standard-deviation-service/random"
 	"github.com/koenno/standard-deviation-service/server"
 	"github.com/koenno/standard-deviation-service/service"
+	"github.com/koenno/standard-deviation-service/store"
+	"github.com/koenno/standard-deviation-service/tracing"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"golang.org/x/exp/slog"
 	"golang.org/x/time/rate"
 )
 
 func main() {
-	reqsPerSec := flag.Int("reqs", 10, "number of requests per second")
-	port := flag.Int("port", 8080, "port number")
-	flag.Parse()
+	cmd, err := config.Load(os.Args[0], os.Args[1:], os.LookupEnv, os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	cfg := cmd.Config
+	if cmd.PrintConfig {
+		if err := cfg.Redacted().Print(os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
 
-	rateLimiter := rate.NewLimiter(rate.Every(time.Second), *reqsPerSec)
-	reqSender := client.New(rateLimiter)
-	respParser := randomorg.NewBodyParser()
-	reqFactory := randomorg.NewRequestFactory()
+	if err := run(cfg); err != nil {
+		slog.Error("server stopped with error", "timestamp", time.Now(), "error", err)
+		os.Exit(1)
+	}
+}
+
+// run serves until SIGINT or SIGTERM and returns an error when the server
+// failed or could not drain within the shutdown grace period.
+func run(cfg config.Config) error {
+	rateLimiter := rate.NewLimiter(rate.Every(time.Second), cfg.RandomOrg.RequestsPerSecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Settings{
+		Exporter:    cfg.Tracing.Exporter,
+		ServiceName: "standard-deviation-service",
+	})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			slog.Error("failed to flush trace spans", "error", err)
+		}
+	}()
 
-	generator := random.NewRandom(reqSender, respParser, reqFactory)
+	m := metrics.New()
+	m.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+
+	clientOpts := []client.ClientOption{
+		client.WithRetryPolicy(cfg.RetryPolicy()),
+		client.WithTimeout(cfg.RandomOrg.HTTPTimeout),
+		client.WithMetrics(m),
+	}
+	// recordings are made and replayed below the retries, one exchange per attempt
+	switch {
+	case cfg.RandomOrg.Replay != "":
+		replayer, err := replay.NewReplayer(cfg.RandomOrg.Replay)
+		if err != nil {
+			return err
+		}
+		clientOpts = append(clientOpts, client.WithTransport(replayer))
+		slog.Info("replaying random.org responses", "timestamp", time.Now(), "file", cfg.RandomOrg.Replay)
+	case cfg.RandomOrg.Record != "":
+		recorder, err := replay.NewRecorder(http.DefaultTransport, cfg.RandomOrg.Record)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := recorder.Close(); err != nil {
+				slog.Error("failed to close the recording", "error", err)
+			}
+		}()
+		clientOpts = append(clientOpts, client.WithTransport(recorder))
+	}
+	circuitBreaker := breaker.New(client.New(rateLimiter, clientOpts...), cfg.BreakerSettings())
+	m.GaugeFunc("breaker_state", "State of the random.org circuit breaker: 0 closed, 1 open, 2 half-open.", func() float64 {
+		return float64(circuitBreaker.State())
+	})
+	randomOrgOpts := []randomorg.Option{
+		randomorg.WithBaseURL(cfg.RandomOrg.URL),
+		randomorg.WithRPCURL(cfg.RandomOrg.RPCURL),
+		randomorg.WithDefaults(client.WithMin(cfg.Defaults.Min), client.WithMax(cfg.Defaults.Max)),
+	}
+	var reqSender random.RequestSender = circuitBreaker
+	var quota *randomorg.QuotaTracker
+	// the quota is checked on random.org itself, which a replay does not reach
+	if cfg.RandomOrg.APIKey == "" && cfg.RandomOrg.QuotaInterval > 0 && cfg.RandomOrg.Replay == "" {
+		quota = randomorg.NewQuotaTracker(circuitBreaker, randomOrgOpts...)
+		reqSender = quota
+		go quota.Run(ctx, cfg.RandomOrg.QuotaInterval)
+		m.GaugeFunc("quota_bits_left", "Remaining random.org bit allowance, NaN until the quota is known.", func() float64 {
+			bits, ok := quota.BitsLeft()
+			if !ok {
+				return math.NaN()
+			}
+			return float64(bits)
+		})
+	}
+	var respParser random.ResponseParser = randomorg.NewBodyParser()
+	var reqFactory random.RequestFactory = randomorg.NewRequestFactory(randomOrgOpts...)
+	var usage *randomorg.RPCBodyParser
+	if cfg.RandomOrg.APIKey != "" {
+		rpcParser := randomorg.NewRPCBodyParser()
+		usage = &rpcParser
+		respParser = rpcParser
+		reqFactory = randomorg.NewRPCRequestFactory(cfg.RandomOrg.APIKey, cfg.RandomOrg.Signed, randomOrgOpts...)
+		m.GaugeFunc("quota_bits_left", "Remaining api.random.org bit allowance of the API key, NaN until the first response.", func() float64 {
+			return knownOrNaN(rpcParser.Usage().BitsLeft)
+		})
+		m.GaugeFunc("quota_requests_left", "Remaining api.random.org request allowance of the API key, NaN until the first response.", func() float64 {
+			return knownOrNaN(rpcParser.Usage().RequestsLeft)
+		})
+	}
+
+	var local *random.Local
+	if cfg.Seed != 0 {
+		local = random.NewSeededLocal(cfg.Seed)
+	} else {
+		local = random.NewLocal()
+	}
+	randomOrgClient := random.NewRandom(reqSender, respParser, reqFactory)
+	var randomOrg server.RandomIntegerGenerator = randomOrgClient
+	if cfg.Batch.Window > 0 {
+		randomOrg = random.NewBatcher(randomOrgClient, cfg.BatchSettings())
+	}
+	var pool *random.Pool
+	if cfg.Pool.Size > 0 {
+		pool = random.NewPool(randomOrg, cfg.PoolSettings())
+		randomOrg = pool
+		go pool.Run(ctx)
+		m.GaugeFunc("pool_buffered_integers", "Number of integers buffered in the pool.", func() float64 {
+			return float64(pool.Buffered())
+		})
+		m.CounterFunc("pool_hits_total", "Number of calls served from the pool.", func() float64 {
+			return float64(pool.Stats().Hits)
+		})
+		m.CounterFunc("pool_misses_total", "Number of calls the pool could not serve.", func() float64 {
+			return float64(pool.Stats().Misses)
+		})
+	}
+	generators := map[string]server.RandomIntegerGenerator{
+		"random.org": randomOrg,
+		"local":      local,
+		"crypto":     random.NewCrypto(),
+	}
+	generator := generators[cfg.Source]
 
 	calculator := service.NewStdDevService()
 
-	srv := server.NewRandomServer(generator, calculator, *port)
+	opts := []server.Option{
+		server.WithLimits(cfg.ServerLimits()),
+		server.WithJobSettings(cfg.JobSettings()),
+		server.WithDefaultSource(cfg.Source),
+		server.WithDefaultRange(cfg.Defaults.Min, cfg.Defaults.Max),
+		server.WithTimeout(cfg.Server.Timeout),
+		server.WithShutdownDelay(cfg.Server.ShutdownDelay),
+		server.WithStatusReporter("breaker", circuitBreaker),
+		server.WithMetrics(m),
+	}
+	if quota != nil {
+		opts = append(opts, server.WithStatusReporter("quota", quota))
+	}
+	if usage != nil {
+		opts = append(opts, server.WithStatusReporter("quota", usage))
+	}
+	// local and crypto generate in process and are always ready
+	generatorReady := func() error { return nil }
+	if cfg.Source == "random.org" {
+		opts = append(opts, server.WithReadinessCheck("breaker", circuitBreaker))
+		generatorReady = circuitBreaker.Ready
+		if quota != nil {
+			opts = append(opts, server.WithReadinessCheck("quota", quota))
+			generatorReady = func() error {
+				return errors.Join(circuitBreaker.Ready(), quota.Reachable())
+			}
+		}
+	}
+	opts = append(opts, server.WithReadinessCheck("generator", server.ReadinessCheckerFunc(generatorReady)))
+	if pool != nil {
+		opts = append(opts, server.WithStatusReporter("pool", pool))
+	}
+	if cfg.Results.DataDir != "" {
+		results, err := store.OpenFile(cfg.Results.DataDir)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := results.Close(); err != nil {
+				slog.Error("failed to close the result store", "error", err)
+			}
+		}()
+		opts = append(opts, server.WithResultStore(results))
+	}
+	for name, g := range generators {
+		opts = append(opts, server.WithGenerator(name, g))
+	}
+	srv := server.NewRandomServer(generator, calculator, cfg.Server.Port, opts...)
 
-	done := make(chan os.Signal, 1)
-	signal.Notify(done, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+	runErr := make(chan error, 1)
 	go func() {
-		<-done
-		srv.Stop()
+		runErr <- srv.Run()
 	}()
 
-	srv.Run()
+	done := make(chan os.Signal, 1)
+	signal.Notify(done, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+	select {
+	case err := <-runErr:
+		return err
+	case <-done:
+	}
+
+	stopCtx, stopCancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownDelay+cfg.Server.ShutdownGrace)
+	defer stopCancel()
+	stopErr := srv.Stop(stopCtx)
+	return errors.Join(<-runErr, stopErr)
+}
+
+// knownOrNaN reports an allowance of -1, which is not known yet, as NaN.
+func knownOrNaN(allowance int64) float64 {
+	if allowance < 0 {
+		return math.NaN()
+	}
+	return float64(allowance)
 }
@@ -0,0 +1,321 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/koenno/standard-deviation-service/breaker"
+	"github.com/koenno/standard-deviation-service/client"
+	"github.com/koenno/standard-deviation-service/client/randomorg"
+	"github.com/koenno/standard-deviation-service/random"
+	"github.com/koenno/standard-deviation-service/server"
+	"github.com/koenno/standard-deviation-service/tracing"
+)
+
+var (
+	ErrInvalid = errors.New("invalid configuration")
+
+	// Sources lists the generators selectable as the default source.
+	Sources = []string{"random.org", "local", "crypto"}
+)
+
+// Config holds every setting of the service. Values are taken from, in
+// increasing order of precedence: defaults, the configuration file, STDDEV_*
+// environment variables and command line flags.
+type Config struct {
+	Server    Server    `yaml:"server"`
+	Limits    Limits    `yaml:"limits"`
+	Jobs      Jobs      `yaml:"jobs"`
+	Defaults  Defaults  `yaml:"defaults"`
+	Source    string    `yaml:"source"`
+	Seed      uint64    `yaml:"seed"`
+	RandomOrg RandomOrg `yaml:"random_org"`
+	Retry     Retry     `yaml:"retry"`
+	Breaker   Breaker   `yaml:"breaker"`
+	Batch     Batch     `yaml:"batch"`
+	Pool      Pool      `yaml:"pool"`
+	Tracing   Tracing   `yaml:"tracing"`
+	Results   Results   `yaml:"results"`
+}
+
+type Server struct {
+	Port          int           `yaml:"port"`
+	Timeout       time.Duration `yaml:"timeout"`
+	ShutdownDelay time.Duration `yaml:"shutdown_delay"`
+	ShutdownGrace time.Duration `yaml:"shutdown_grace"`
+}
+
+type Limits struct {
+	MaxRequests    int `yaml:"max_requests"`
+	MaxLength      int `yaml:"max_length"`
+	MaxTotal       int `yaml:"max_total"`
+	MaxConcurrency int `yaml:"max_concurrency"`
+}
+
+// Jobs configures the asynchronous /jobs API. Its limits replace the
+// request limits for jobs.
+type Jobs struct {
+	Workers        int           `yaml:"workers"`
+	QueueSize      int           `yaml:"queue_size"`
+	TTL            time.Duration `yaml:"ttl"`
+	MaxRetained    int           `yaml:"max_retained"`
+	MaxRequests    int           `yaml:"max_requests"`
+	MaxLength      int           `yaml:"max_length"`
+	MaxTotal       int           `yaml:"max_total"`
+	MaxConcurrency int           `yaml:"max_concurrency"`
+}
+
+// Defaults is the range of integers used when a request omits min and max.
+type Defaults struct {
+	Min int `yaml:"min"`
+	Max int `yaml:"max"`
+}
+
+type RandomOrg struct {
+	URL               string        `yaml:"url"`
+	RPCURL            string        `yaml:"rpc_url"`
+	APIKey            string        `yaml:"api_key"`
+	Signed            bool          `yaml:"signed"`
+	RequestsPerSecond int           `yaml:"requests_per_second"`
+	HTTPTimeout       time.Duration `yaml:"http_timeout"`
+	QuotaInterval     time.Duration `yaml:"quota_interval"`
+	// Record names a file every random.org response is appended to, Replay
+	// a recording to answer requests from instead of random.org.
+	Record string `yaml:"record"`
+	Replay string `yaml:"replay"`
+}
+
+type Retry struct {
+	Attempts    int           `yaml:"attempts"`
+	BaseBackoff time.Duration `yaml:"base_backoff"`
+	MaxBackoff  time.Duration `yaml:"max_backoff"`
+	Jitter      float64       `yaml:"jitter"`
+}
+
+type Breaker struct {
+	Interval     time.Duration `yaml:"interval"`
+	MinRequests  int           `yaml:"min_requests"`
+	FailureRatio float64       `yaml:"failure_ratio"`
+	Cooldown     time.Duration `yaml:"cooldown"`
+}
+
+type Batch struct {
+	Window time.Duration `yaml:"window"`
+}
+
+type Pool struct {
+	Size     int `yaml:"size"`
+	LowWater int `yaml:"low_water"`
+	Batch    int `yaml:"batch"`
+	Min      int `yaml:"min"`
+	Max      int `yaml:"max"`
+}
+
+type Tracing struct {
+	Exporter string `yaml:"exporter"`
+}
+
+// Results configures the store of calculated results. An empty DataDir
+// disables recording.
+type Results struct {
+	DataDir string `yaml:"data_dir"`
+}
+
+func Default() Config {
+	limits := server.DefaultLimits()
+	jobs := server.DefaultJobSettings()
+	retry := client.DefaultRetryPolicy()
+	breakerSettings := breaker.DefaultSettings()
+	pool := random.DefaultPoolSettings()
+	defaults := client.NewOptions()
+	return Config{
+		Server: Server{
+			Port:          8080,
+			Timeout:       60 * time.Second,
+			ShutdownGrace: 30 * time.Second,
+		},
+		Limits: Limits{
+			MaxRequests:    limits.MaxRequests,
+			MaxLength:      limits.MaxLength,
+			MaxTotal:       limits.MaxTotal,
+			MaxConcurrency: limits.MaxConcurrency,
+		},
+		Jobs: Jobs{
+			Workers:        jobs.Workers,
+			QueueSize:      jobs.QueueSize,
+			TTL:            jobs.TTL,
+			MaxRetained:    jobs.MaxRetained,
+			MaxRequests:    jobs.Limits.MaxRequests,
+			MaxLength:      jobs.Limits.MaxLength,
+			MaxTotal:       jobs.Limits.MaxTotal,
+			MaxConcurrency: jobs.Limits.MaxConcurrency,
+		},
+		Defaults: Defaults{
+			Min: defaults.Min,
+			Max: defaults.Max,
+		},
+		Source: "random.org",
+		RandomOrg: RandomOrg{
+			URL:               randomorg.DefaultBaseURL,
+			RPCURL:            randomorg.DefaultRPCURL,
+			RequestsPerSecond: 10,
+			HTTPTimeout:       10 * time.Second,
+			QuotaInterval:     time.Minute,
+		},
+		Retry: Retry{
+			Attempts:    retry.MaxAttempts,
+			BaseBackoff: retry.BaseBackoff,
+			MaxBackoff:  retry.MaxBackoff,
+			Jitter:      retry.Jitter,
+		},
+		Breaker: Breaker{
+			Interval:     breakerSettings.Interval,
+			MinRequests:  breakerSettings.MinRequests,
+			FailureRatio: breakerSettings.FailureRatio,
+			Cooldown:     breakerSettings.Cooldown,
+		},
+		Batch: Batch{
+			Window: random.DefaultBatchSettings().Window,
+		},
+		Pool: Pool{
+			LowWater: pool.LowWater,
+			Batch:    pool.BatchSize,
+			Min:      pool.Min,
+			Max:      pool.Max,
+		},
+		Tracing: Tracing{
+			Exporter: tracing.ExporterNone,
+		},
+	}
+}
+
+// Validate reports every invalid setting at once.
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Server.Port > 0 && c.Server.Port <= 65535, "server.port must be within [1, 65535], got %d", c.Server.Port)
+	check(c.Server.Timeout > 0, "server.timeout must be positive, got %s", c.Server.Timeout)
+	check(c.Server.ShutdownDelay >= 0, "server.shutdown_delay must not be negative, got %s", c.Server.ShutdownDelay)
+	check(c.Server.ShutdownGrace >= 0, "server.shutdown_grace must not be negative, got %s", c.Server.ShutdownGrace)
+	check(c.Limits.MaxRequests >= 0, "limits.max_requests must not be negative, got %d", c.Limits.MaxRequests)
+	check(c.Limits.MaxLength >= 0, "limits.max_length must not be negative, got %d", c.Limits.MaxLength)
+	check(c.Limits.MaxTotal >= 0, "limits.max_total must not be negative, got %d", c.Limits.MaxTotal)
+	check(c.Limits.MaxConcurrency >= 0, "limits.max_concurrency must not be negative, got %d", c.Limits.MaxConcurrency)
+	check(c.Jobs.Workers >= 1, "jobs.workers must be at least 1, got %d", c.Jobs.Workers)
+	check(c.Jobs.QueueSize >= 0, "jobs.queue_size must not be negative, got %d", c.Jobs.QueueSize)
+	check(c.Jobs.TTL > 0, "jobs.ttl must be positive, got %s", c.Jobs.TTL)
+	check(c.Jobs.MaxRetained >= 0, "jobs.max_retained must not be negative, got %d", c.Jobs.MaxRetained)
+	check(c.Jobs.MaxRequests >= 0, "jobs.max_requests must not be negative, got %d", c.Jobs.MaxRequests)
+	check(c.Jobs.MaxLength >= 0, "jobs.max_length must not be negative, got %d", c.Jobs.MaxLength)
+	check(c.Jobs.MaxTotal >= 0, "jobs.max_total must not be negative, got %d", c.Jobs.MaxTotal)
+	check(c.Jobs.MaxConcurrency >= 0, "jobs.max_concurrency must not be negative, got %d", c.Jobs.MaxConcurrency)
+	check(c.Defaults.Min < c.Defaults.Max, "defaults.min must be less than defaults.max, got %d and %d", c.Defaults.Min, c.Defaults.Max)
+	check(slices.Contains(Sources, c.Source), "source must be one of %v, got %q", Sources, c.Source)
+	check(validURL(c.RandomOrg.URL), "random_org.url must be an absolute URL, got %q", c.RandomOrg.URL)
+	check(validURL(c.RandomOrg.RPCURL), "random_org.rpc_url must be an absolute URL, got %q", c.RandomOrg.RPCURL)
+	check(c.RandomOrg.RequestsPerSecond > 0, "random_org.requests_per_second must be positive, got %d", c.RandomOrg.RequestsPerSecond)
+	check(c.RandomOrg.HTTPTimeout > 0, "random_org.http_timeout must be positive, got %s", c.RandomOrg.HTTPTimeout)
+	check(c.RandomOrg.QuotaInterval >= 0, "random_org.quota_interval must not be negative, got %s", c.RandomOrg.QuotaInterval)
+	check(c.RandomOrg.Record == "" || c.RandomOrg.Replay == "", "random_org.record and random_org.replay must not both be set")
+	check(c.Retry.Attempts >= 1, "retry.attempts must be at least 1, got %d", c.Retry.Attempts)
+	check(c.Retry.BaseBackoff >= 0, "retry.base_backoff must not be negative, got %s", c.Retry.BaseBackoff)
+	check(c.Retry.MaxBackoff >= c.Retry.BaseBackoff, "retry.max_backoff must not be less than retry.base_backoff, got %s", c.Retry.MaxBackoff)
+	check(c.Retry.Jitter >= 0 && c.Retry.Jitter <= 1, "retry.jitter must be within [0, 1], got %g", c.Retry.Jitter)
+	check(c.Breaker.Interval > 0, "breaker.interval must be positive, got %s", c.Breaker.Interval)
+	check(c.Breaker.MinRequests >= 1, "breaker.min_requests must be at least 1, got %d", c.Breaker.MinRequests)
+	check(c.Breaker.FailureRatio > 0 && c.Breaker.FailureRatio <= 1, "breaker.failure_ratio must be within (0, 1], got %g", c.Breaker.FailureRatio)
+	check(c.Breaker.Cooldown > 0, "breaker.cooldown must be positive, got %s", c.Breaker.Cooldown)
+	check(c.Batch.Window >= 0, "batch.window must not be negative, got %s", c.Batch.Window)
+	check(c.Pool.Size >= 0, "pool.size must not be negative, got %d", c.Pool.Size)
+	if c.Pool.Size > 0 {
+		check(c.Pool.LowWater >= 0 && c.Pool.LowWater <= c.Pool.Size, "pool.low_water must be within [0, pool.size], got %d", c.Pool.LowWater)
+		check(c.Pool.Batch > 0, "pool.batch must be positive, got %d", c.Pool.Batch)
+		check(c.Pool.Min < c.Pool.Max, "pool.min must be less than pool.max, got %d and %d", c.Pool.Min, c.Pool.Max)
+	}
+	validExporters := []string{tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterOTLP}
+	check(slices.Contains(validExporters, c.Tracing.Exporter), "tracing.exporter must be one of %v, got %q", validExporters, c.Tracing.Exporter)
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %w", ErrInvalid, errors.Join(errs...))
+	}
+	return nil
+}
+
+// Redacted returns a copy safe to print, without secrets.
+func (c Config) Redacted() Config {
+	if c.RandomOrg.APIKey != "" {
+		c.RandomOrg.APIKey = "********"
+	}
+	return c
+}
+
+func (c Config) ServerLimits() server.Limits {
+	return server.Limits{
+		MaxRequests:    c.Limits.MaxRequests,
+		MaxLength:      c.Limits.MaxLength,
+		MaxTotal:       c.Limits.MaxTotal,
+		MaxConcurrency: c.Limits.MaxConcurrency,
+	}
+}
+
+func (c Config) JobSettings() server.JobSettings {
+	return server.JobSettings{
+		Workers:     c.Jobs.Workers,
+		QueueSize:   c.Jobs.QueueSize,
+		TTL:         c.Jobs.TTL,
+		MaxRetained: c.Jobs.MaxRetained,
+		Limits: server.Limits{
+			MaxRequests:    c.Jobs.MaxRequests,
+			MaxLength:      c.Jobs.MaxLength,
+			MaxTotal:       c.Jobs.MaxTotal,
+			MaxConcurrency: c.Jobs.MaxConcurrency,
+		},
+	}
+}
+
+func (c Config) RetryPolicy() client.RetryPolicy {
+	return client.RetryPolicy{
+		MaxAttempts: c.Retry.Attempts,
+		BaseBackoff: c.Retry.BaseBackoff,
+		MaxBackoff:  c.Retry.MaxBackoff,
+		Jitter:      c.Retry.Jitter,
+	}
+}
+
+func (c Config) BreakerSettings() breaker.Settings {
+	settings := breaker.DefaultSettings()
+	settings.Interval = c.Breaker.Interval
+	settings.MinRequests = c.Breaker.MinRequests
+	settings.FailureRatio = c.Breaker.FailureRatio
+	settings.Cooldown = c.Breaker.Cooldown
+	return settings
+}
+
+func (c Config) BatchSettings() random.BatchSettings {
+	settings := random.DefaultBatchSettings()
+	settings.Window = c.Batch.Window
+	return settings
+}
+
+func (c Config) PoolSettings() random.PoolSettings {
+	settings := random.DefaultPoolSettings()
+	settings.Size = c.Pool.Size
+	settings.LowWater = c.Pool.LowWater
+	settings.BatchSize = c.Pool.Batch
+	settings.Min = c.Pool.Min
+	settings.Max = c.Pool.Max
+	return settings
+}
+
+func validURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
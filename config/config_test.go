@@ -0,0 +1,212 @@
+package config
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func env(vars map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := vars[key]
+		return value, ok
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	err := os.WriteFile(path, []byte(content), 0o600)
+	assert.NoError(t, err)
+	return path
+}
+
+func TestShouldLoadValidDefaults(t *testing.T) {
+	// when
+	cmd, err := Load("test", nil, env(nil), io.Discard)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, Default(), cmd.Config)
+	assert.False(t, cmd.PrintConfig)
+}
+
+func TestShouldApplySourcesInOrderOfPrecedence(t *testing.T) {
+	// given
+	file := writeFile(t, "config.yaml", `
+server:
+  port: 9090
+  timeout: 15s
+retry:
+  attempts: 5
+  jitter: 0.2
+random_org:
+  url: http://localhost:8081
+`)
+	vars := map[string]string{
+		"STDDEV_CONFIG":         file,
+		"STDDEV_RETRY_ATTEMPTS": "4",
+		"STDDEV_PORT":           "9191",
+	}
+	args := []string{"-port", "9292", "-signed"}
+
+	// when
+	cmd, err := Load("test", args, env(vars), io.Discard)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, file, cmd.ConfigFile)
+	assert.Equal(t, 9292, cmd.Config.Server.Port)
+	assert.Equal(t, 15*time.Second, cmd.Config.Server.Timeout)
+	assert.Equal(t, 4, cmd.Config.Retry.Attempts)
+	assert.Equal(t, 0.2, cmd.Config.Retry.Jitter)
+	assert.Equal(t, "http://localhost:8081", cmd.Config.RandomOrg.URL)
+	assert.True(t, cmd.Config.RandomOrg.Signed)
+	assert.Equal(t, Default().Breaker, cmd.Config.Breaker)
+}
+
+func TestShouldPreferConfigFlagOverEnvironment(t *testing.T) {
+	// given
+	envFile := writeFile(t, "env.yaml", "source: crypto\n")
+	flagFile := writeFile(t, "flag.json", `{"source": "local", "pool": {"size": 20000, "low_water": 5000}}`)
+	args := []string{"-reqs", "3", "-signed", "-config=" + flagFile}
+
+	// when
+	cmd, err := Load("test", args, env(map[string]string{"STDDEV_CONFIG": envFile}), io.Discard)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, flagFile, cmd.ConfigFile)
+	assert.Equal(t, "local", cmd.Config.Source)
+	assert.Equal(t, 20000, cmd.Config.Pool.Size)
+	assert.Equal(t, 5000, cmd.Config.Pool.LowWater)
+	assert.Equal(t, 3, cmd.Config.RandomOrg.RequestsPerSecond)
+}
+
+func TestShouldRejectInvalidSources(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		vars     map[string]string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "unknown field in file",
+			file:     "servre:\n  port: 1\n",
+			expected: "field servre not found",
+		},
+		{
+			name:     "malformed duration in file",
+			file:     "server:\n  timeout: soon\n",
+			expected: "soon",
+		},
+		{
+			name:     "malformed environment variable",
+			vars:     map[string]string{"STDDEV_MAX_LENGTH": "many"},
+			expected: `invalid value "many" for STDDEV_MAX_LENGTH`,
+		},
+		{
+			name:     "unknown flag",
+			args:     []string{"-colour"},
+			expected: "flag provided but not defined: -colour",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// given
+			vars := test.vars
+			if test.file != "" {
+				vars = map[string]string{"STDDEV_CONFIG": writeFile(t, "config.yaml", test.file)}
+			}
+
+			// when
+			_, err := Load("test", test.args, env(vars), io.Discard)
+
+			// then
+			assert.ErrorContains(t, err, test.expected)
+		})
+	}
+}
+
+func TestShouldReturnErrHelp(t *testing.T) {
+	// given
+	var output bytes.Buffer
+
+	// when
+	_, err := Load("test", []string{"-h"}, env(nil), &output)
+
+	// then
+	assert.ErrorIs(t, err, flag.ErrHelp)
+	assert.Contains(t, output.String(), "-max-length")
+}
+
+func TestShouldReportEveryInvalidSetting(t *testing.T) {
+	// given
+	cfg := Default()
+	cfg.Server.Port = 0
+	cfg.Defaults.Min = 10
+	cfg.Jobs.Workers = 0
+	cfg.Source = "dice"
+	cfg.RandomOrg.URL = "www.random.org"
+	cfg.RandomOrg.Record = "incident.jsonl"
+	cfg.RandomOrg.Replay = "incident.jsonl"
+	cfg.Retry.Jitter = 2
+	cfg.Tracing.Exporter = "zipkin"
+
+	// when
+	err := cfg.Validate()
+
+	// then
+	assert.ErrorIs(t, err, ErrInvalid)
+	assert.ErrorContains(t, err, "server.port must be within [1, 65535], got 0")
+	assert.ErrorContains(t, err, "jobs.workers must be at least 1, got 0")
+	assert.ErrorContains(t, err, "defaults.min must be less than defaults.max, got 10 and 10")
+	assert.ErrorContains(t, err, `source must be one of [random.org local crypto], got "dice"`)
+	assert.ErrorContains(t, err, `random_org.url must be an absolute URL, got "www.random.org"`)
+	assert.ErrorContains(t, err, "random_org.record and random_org.replay must not both be set")
+	assert.ErrorContains(t, err, "retry.jitter must be within [0, 1], got 2")
+	assert.ErrorContains(t, err, `tracing.exporter must be one of [none stdout otlp], got "zipkin"`)
+}
+
+func TestShouldValidatePoolOnlyWhenEnabled(t *testing.T) {
+	// given
+	cfg := Default()
+	cfg.Pool.Min, cfg.Pool.Max = 5, 5
+
+	// when
+	disabledErr := cfg.Validate()
+	cfg.Pool.Size = 50_000
+	enabledErr := cfg.Validate()
+
+	// then
+	assert.NoError(t, disabledErr)
+	assert.ErrorContains(t, enabledErr, "pool.min must be less than pool.max")
+}
+
+func TestShouldPrintConfigurationReadableByLoad(t *testing.T) {
+	// given
+	cfg := Default()
+	cfg.Server.ShutdownGrace = 5 * time.Second
+	cfg.Pool.Size = 20_000
+	cfg.RandomOrg.APIKey = "secret"
+	var printed bytes.Buffer
+
+	// when
+	err := cfg.Redacted().Print(&printed)
+
+	// then
+	assert.NoError(t, err)
+	assert.NotContains(t, printed.String(), "secret")
+	assert.Contains(t, printed.String(), "shutdown_grace: 5s")
+	cmd, err := Load("test", []string{"-config", writeFile(t, "printed.yaml", printed.String())}, env(nil), io.Discard)
+	assert.NoError(t, err)
+	expected := cfg.Redacted()
+	assert.Equal(t, expected, cmd.Config)
+}
@@ -0,0 +1,195 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix prefixes the environment variable of every flag, e.g. -max-length
+// is read from STDDEV_MAX_LENGTH.
+const EnvPrefix = "STDDEV_"
+
+// Command is the outcome of parsing the command line.
+type Command struct {
+	Config      Config
+	ConfigFile  string
+	PrintConfig bool
+}
+
+// Load reads the configuration file named by -config or STDDEV_CONFIG, then
+// applies STDDEV_* environment variables and finally the flags in args.
+// The result is validated. flag.ErrHelp is returned when help was requested.
+func Load(name string, args []string, lookupEnv func(string) (string, bool), output io.Writer) (Command, error) {
+	cmd := Command{Config: Default()}
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(output)
+	cmd.bind(fs)
+
+	if file, ok := lookupEnv(EnvPrefix + "CONFIG"); ok {
+		cmd.ConfigFile = file
+	}
+	if file, ok := configFileArg(fs, args); ok {
+		cmd.ConfigFile = file
+	}
+	if cmd.ConfigFile != "" {
+		err := cmd.Config.readFile(cmd.ConfigFile)
+		if err != nil {
+			return Command{}, err
+		}
+	}
+
+	var envErrs []error
+	fs.VisitAll(func(f *flag.Flag) {
+		env := envName(f.Name)
+		value, ok := lookupEnv(env)
+		if !ok {
+			return
+		}
+		if err := fs.Set(f.Name, value); err != nil {
+			envErrs = append(envErrs, fmt.Errorf("invalid value %q for %s: %w", value, env, err))
+		}
+	})
+	if len(envErrs) > 0 {
+		return Command{}, fmt.Errorf("%w: %w", ErrInvalid, errors.Join(envErrs...))
+	}
+
+	err := fs.Parse(args)
+	if err != nil {
+		return Command{}, err
+	}
+
+	err = cmd.Config.Validate()
+	if err != nil {
+		return Command{}, err
+	}
+	return cmd, nil
+}
+
+// Print writes the configuration as YAML, in the format accepted by -config.
+func (c Config) Print(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	err := enc.Encode(c)
+	if err != nil {
+		return fmt.Errorf("failed to encode the configuration: %w", err)
+	}
+	return enc.Close()
+}
+
+// readFile overlays the settings present in a YAML or JSON file.
+func (c *Config) readFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open the configuration file: %w", err)
+	}
+	defer f.Close()
+
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	err = dec.Decode(c)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("%w: %s: %w", ErrInvalid, path, err)
+	}
+	return nil
+}
+
+func (cmd *Command) bind(fs *flag.FlagSet) {
+	c := &cmd.Config
+	fs.StringVar(&cmd.ConfigFile, "config", cmd.ConfigFile, "YAML or JSON configuration file")
+	fs.BoolVar(&cmd.PrintConfig, "print-config", false, "print the effective configuration and exit")
+
+	fs.IntVar(&c.Server.Port, "port", c.Server.Port, "port number")
+	fs.DurationVar(&c.Server.Timeout, "server-timeout", c.Server.Timeout, "maximum duration of a single request")
+	fs.DurationVar(&c.Server.ShutdownDelay, "shutdown-delay", c.Server.ShutdownDelay, "time the service keeps serving after failing /readyz on shutdown")
+	fs.DurationVar(&c.Server.ShutdownGrace, "shutdown-grace", c.Server.ShutdownGrace, "time in-flight requests are given to finish on shutdown")
+
+	fs.IntVar(&c.Limits.MaxRequests, "max-requests", c.Limits.MaxRequests, "maximum number of requests per calculation, 0 disables the limit")
+	fs.IntVar(&c.Limits.MaxLength, "max-length", c.Limits.MaxLength, "maximum length of a single set, 0 disables the limit")
+	fs.IntVar(&c.Limits.MaxTotal, "max-total", c.Limits.MaxTotal, "maximum number of requests multiplied by length, 0 disables the limit")
+	fs.IntVar(&c.Limits.MaxConcurrency, "max-concurrency", c.Limits.MaxConcurrency, "maximum number of sets of a calculation generated at once, 0 disables the limit")
+	fs.IntVar(&c.Jobs.Workers, "job-workers", c.Jobs.Workers, "number of jobs run concurrently")
+	fs.IntVar(&c.Jobs.QueueSize, "job-queue-size", c.Jobs.QueueSize, "number of jobs waiting for a worker before new ones are rejected")
+	fs.DurationVar(&c.Jobs.TTL, "job-ttl", c.Jobs.TTL, "how long a finished job is kept")
+	fs.IntVar(&c.Jobs.MaxRetained, "job-max-retained", c.Jobs.MaxRetained, "maximum number of integers the kept jobs may hold, 0 disables the limit")
+	fs.IntVar(&c.Jobs.MaxRequests, "job-max-requests", c.Jobs.MaxRequests, "maximum number of sets per job, 0 disables the limit")
+	fs.IntVar(&c.Jobs.MaxLength, "job-max-length", c.Jobs.MaxLength, "maximum length of a single set of a job, 0 disables the limit")
+	fs.IntVar(&c.Jobs.MaxTotal, "job-max-total", c.Jobs.MaxTotal, "maximum sum of set lengths per job, 0 disables the limit")
+	fs.IntVar(&c.Jobs.MaxConcurrency, "job-max-concurrency", c.Jobs.MaxConcurrency, "maximum number of sets of a job generated at once, 0 disables the limit")
+	fs.IntVar(&c.Defaults.Min, "default-min", c.Defaults.Min, "smallest integer drawn when a request omits min")
+	fs.IntVar(&c.Defaults.Max, "default-max", c.Defaults.Max, "largest integer drawn when a request omits max")
+
+	fs.StringVar(&c.Source, "source", c.Source, "default source of random integers: random.org, local or crypto")
+	fs.Uint64Var(&c.Seed, "seed", c.Seed, "seed of the local source, 0 seeds it randomly")
+
+	fs.StringVar(&c.RandomOrg.URL, "random-org-url", c.RandomOrg.URL, "base URL of the random.org /integers/ and /quota/ endpoints")
+	fs.StringVar(&c.RandomOrg.RPCURL, "random-org-rpc-url", c.RandomOrg.RPCURL, "URL of the random.org JSON-RPC API")
+	fs.StringVar(&c.RandomOrg.APIKey, "api-key", c.RandomOrg.APIKey, "api.random.org key, switches random.org to the JSON-RPC API")
+	fs.BoolVar(&c.RandomOrg.Signed, "signed", c.RandomOrg.Signed, "request signed integers from the JSON-RPC API")
+	fs.IntVar(&c.RandomOrg.RequestsPerSecond, "reqs", c.RandomOrg.RequestsPerSecond, "number of requests per second")
+	fs.DurationVar(&c.RandomOrg.HTTPTimeout, "http-timeout", c.RandomOrg.HTTPTimeout, "timeout of a single random.org request attempt")
+	fs.DurationVar(&c.RandomOrg.QuotaInterval, "quota-interval", c.RandomOrg.QuotaInterval, "how often the random.org bit quota is checked, 0 disables quota tracking")
+	fs.StringVar(&c.RandomOrg.Record, "record", c.RandomOrg.Record, "file every random.org request and response is appended to")
+	fs.StringVar(&c.RandomOrg.Replay, "replay", c.RandomOrg.Replay, "file of recorded random.org responses served instead of calling random.org")
+
+	fs.IntVar(&c.Retry.Attempts, "retry-attempts", c.Retry.Attempts, "maximum number of attempts per random.org request, 1 disables retries")
+	fs.DurationVar(&c.Retry.BaseBackoff, "retry-base-backoff", c.Retry.BaseBackoff, "backoff before the first retry, doubled on every next one")
+	fs.DurationVar(&c.Retry.MaxBackoff, "retry-max-backoff", c.Retry.MaxBackoff, "maximum backoff between retries")
+	fs.Float64Var(&c.Retry.Jitter, "retry-jitter", c.Retry.Jitter, "randomized fraction of the backoff within [0, 1]")
+
+	fs.DurationVar(&c.Breaker.Interval, "breaker-interval", c.Breaker.Interval, "window in which random.org failures are counted")
+	fs.IntVar(&c.Breaker.MinRequests, "breaker-min-requests", c.Breaker.MinRequests, "number of requests within the window required to open the circuit")
+	fs.Float64Var(&c.Breaker.FailureRatio, "breaker-failure-ratio", c.Breaker.FailureRatio, "ratio of failed requests within the window that opens the circuit")
+	fs.DurationVar(&c.Breaker.Cooldown, "breaker-cooldown", c.Breaker.Cooldown, "time the circuit stays open before probing random.org again")
+
+	fs.DurationVar(&c.Batch.Window, "batch-window", c.Batch.Window, "how long concurrent random.org calls are collected into one request, 0 disables batching")
+
+	fs.IntVar(&c.Pool.Size, "pool-size", c.Pool.Size, "number of random.org integers buffered in advance, 0 disables the pool")
+	fs.IntVar(&c.Pool.LowWater, "pool-low-water", c.Pool.LowWater, "number of buffered integers below which the pool is refilled")
+	fs.IntVar(&c.Pool.Batch, "pool-batch", c.Pool.Batch, "number of integers requested from random.org per refill request")
+	fs.IntVar(&c.Pool.Min, "pool-min", c.Pool.Min, "smallest integer buffered by the pool")
+	fs.IntVar(&c.Pool.Max, "pool-max", c.Pool.Max, "largest integer buffered by the pool")
+
+	fs.StringVar(&c.Tracing.Exporter, "trace-exporter", c.Tracing.Exporter, "exporter of trace spans: none, stdout or otlp")
+
+	fs.StringVar(&c.Results.DataDir, "data-dir", c.Results.DataDir, "directory in which every calculated result is recorded, empty disables recording")
+}
+
+// configFileArg finds the -config flag before the flags are parsed, so that
+// the file can be read before the flags override it.
+func configFileArg(fs *flag.FlagSet, args []string) (string, bool) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" || !strings.HasPrefix(arg, "-") {
+			return "", false
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if name == "config" {
+			if hasValue {
+				return value, true
+			}
+			if i+1 < len(args) {
+				return args[i+1], true
+			}
+			return "", false
+		}
+		f := fs.Lookup(name)
+		if hasValue || f == nil {
+			continue
+		}
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+			continue
+		}
+		i++
+	}
+	return "", false
+}
+
+func envName(flagName string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
@@ -0,0 +1,106 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "stddev"
+
+// Metrics holds the collectors shared by the server, random and client layers.
+// Every Metrics has its own registry, so instances do not interfere with each other.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	HTTPRequests        *prometheus.CounterVec
+	HTTPRequestDuration *prometheus.HistogramVec
+	UpstreamRequests    *prometheus.CounterVec
+	UpstreamDuration    *prometheus.HistogramVec
+	RateLimiterWait     prometheus.Histogram
+	GeneratedIntegers   *prometheus.CounterVec
+	MeanInFlight        prometheus.Gauge
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		HTTPRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Number of handled HTTP requests by route, method and status code.",
+		}, []string{"route", "method", "status"}),
+		HTTPRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Latency of handled HTTP requests by route, method and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+		UpstreamRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "upstream_requests_total",
+			Help:      "Number of requests sent upstream by status code, or error when no response was received.",
+		}, []string{"status"}),
+		UpstreamDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "upstream_request_duration_seconds",
+			Help:      "Latency of requests sent upstream by status code, or error when no response was received.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"status"}),
+		RateLimiterWait: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "rate_limiter_wait_seconds",
+			Help:      "Time spent waiting for the rate limiter before an upstream request.",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 4, 8),
+		}),
+		GeneratedIntegers: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "generated_integers_total",
+			Help:      "Number of integers generated for calculations by source.",
+		}, []string{"source"}),
+		MeanInFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "mean_pipelines_in_flight",
+			Help:      "Number of standard deviation calculations in progress.",
+		}),
+	}
+	m.registry.MustRegister(
+		m.HTTPRequests,
+		m.HTTPRequestDuration,
+		m.UpstreamRequests,
+		m.UpstreamDuration,
+		m.RateLimiterWait,
+		m.GeneratedIntegers,
+		m.MeanInFlight,
+	)
+	return m
+}
+
+// MustRegister adds collectors, e.g. gauges of optional components, to the registry.
+func (m *Metrics) MustRegister(collectors ...prometheus.Collector) {
+	m.registry.MustRegister(collectors...)
+}
+
+// GaugeFunc registers a gauge whose value is read from fn on every scrape.
+func (m *Metrics) GaugeFunc(name, help string, fn func() float64) {
+	m.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      name,
+		Help:      help,
+	}, fn))
+}
+
+// CounterFunc registers a counter whose value is read from fn on every scrape.
+func (m *Metrics) CounterFunc(name, help string, fn func() float64) {
+	m.MustRegister(prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      name,
+		Help:      help,
+	}, fn))
+}
+
+// Handler serves the registry in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/common/expfmt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShouldExposeRegisteredMetrics(t *testing.T) {
+	// given
+	sut := New()
+	sut.GaugeFunc("test_gauge", "Test gauge.", func() float64 { return 42 })
+	sut.CounterFunc("test_total", "Test counter.", func() float64 { return 7 })
+	sut.GeneratedIntegers.WithLabelValues("local").Add(5)
+	w := httptest.NewRecorder()
+
+	// when
+	sut.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	// then
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(w.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, 42.0, families["stddev_test_gauge"].GetMetric()[0].GetGauge().GetValue())
+	assert.Equal(t, 7.0, families["stddev_test_total"].GetMetric()[0].GetCounter().GetValue())
+	generated := families["stddev_generated_integers_total"].GetMetric()
+	assert.Len(t, generated, 1)
+	assert.Equal(t, "local", generated[0].GetLabel()[0].GetValue())
+	assert.Equal(t, 5.0, generated[0].GetCounter().GetValue())
+}
+
+func TestShouldKeepInstancesIndependent(t *testing.T) {
+	// given
+	first, second := New(), New()
+
+	// when
+	first.MeanInFlight.Inc()
+
+	// then
+	w := httptest.NewRecorder()
+	second.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(w.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.0, families["stddev_mean_pipelines_in_flight"].GetMetric()[0].GetGauge().GetValue())
+}
@@ -0,0 +1,162 @@
+package random
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// BatchSettings configure how a Batcher merges calls. Calls for the same range
+// arriving within Window are sent as a single request of up to MaxQuantity integers.
+type BatchSettings struct {
+	Window      time.Duration
+	MaxQuantity int
+}
+
+func DefaultBatchSettings() BatchSettings {
+	return BatchSettings{
+		Window:      5 * time.Millisecond,
+		MaxQuantity: MaxQuantityPerRequest,
+	}
+}
+
+type batchKey struct {
+	min, max int
+}
+
+type batchResult struct {
+	ints []int
+	err  error
+}
+
+type batchWaiter struct {
+	quantity int
+	result   chan batchResult
+}
+
+type batch struct {
+	key      batchKey
+	quantity int
+	waiters  []*batchWaiter
+	timer    *time.Timer
+	ctx      context.Context
+	cancel   context.CancelFunc
+	waiting  int
+}
+
+// Batcher coalesces concurrent calls for the same range into a single upstream
+// request and splits the returned integers back between the callers.
+type Batcher struct {
+	random   Random
+	settings BatchSettings
+
+	mu      sync.Mutex
+	pending map[batchKey]*batch
+}
+
+func NewBatcher(random Random, settings BatchSettings) *Batcher {
+	return &Batcher{
+		random:   random,
+		settings: settings,
+		pending:  make(map[batchKey]*batch),
+	}
+}
+
+// Integers waits for the batch the call was merged into. Calls too large to
+// share a request go to the upstream directly.
+func (b *Batcher) Integers(ctx context.Context, quantity, min, max int) ([]int, error) {
+	if quantity >= b.settings.MaxQuantity {
+		return b.random.Integers(ctx, quantity, min, max)
+	}
+
+	w := &batchWaiter{
+		quantity: quantity,
+		result:   make(chan batchResult, 1),
+	}
+	pending := b.enqueue(ctx, batchKey{min: min, max: max}, w)
+
+	select {
+	case res := <-w.result:
+		return res.ints, res.err
+	case <-ctx.Done():
+		b.abandon(pending)
+		return nil, ctx.Err()
+	}
+}
+
+func (b *Batcher) enqueue(ctx context.Context, key batchKey, w *batchWaiter) *batch {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	pending := b.pending[key]
+	if pending != nil && pending.quantity+w.quantity > b.settings.MaxQuantity {
+		b.flushLocked(pending)
+		pending = nil
+	}
+	if pending == nil {
+		pending = &batch{key: key}
+		// the batch outlives the call that opened it, so only its values are kept
+		pending.ctx, pending.cancel = context.WithCancel(context.WithoutCancel(ctx))
+		pending.timer = time.AfterFunc(b.settings.Window, func() {
+			b.flush(pending)
+		})
+		b.pending[key] = pending
+	}
+	pending.quantity += w.quantity
+	pending.waiters = append(pending.waiters, w)
+	pending.waiting++
+
+	if pending.quantity == b.settings.MaxQuantity {
+		b.flushLocked(pending)
+	}
+	return pending
+}
+
+// abandon drops the batch, or cancels its upstream request, once every caller gave up.
+func (b *Batcher) abandon(pending *batch) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	pending.waiting--
+	if pending.waiting > 0 {
+		return
+	}
+	if b.pending[pending.key] == pending {
+		delete(b.pending, pending.key)
+		pending.timer.Stop()
+	}
+	pending.cancel()
+}
+
+func (b *Batcher) flush(pending *batch) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.flushLocked(pending)
+}
+
+func (b *Batcher) flushLocked(pending *batch) {
+	if b.pending[pending.key] != pending {
+		return
+	}
+	delete(b.pending, pending.key)
+	pending.timer.Stop()
+	go b.send(pending)
+}
+
+func (b *Batcher) send(pending *batch) {
+	defer pending.cancel()
+
+	ints, err := b.random.Integers(pending.ctx, pending.quantity, pending.key.min, pending.key.max)
+	if err == nil && len(ints) < pending.quantity {
+		err = fmt.Errorf("%w: got %d integers, expected %d", ErrItems, len(ints), pending.quantity)
+	}
+	for _, w := range pending.waiters {
+		if err != nil {
+			w.result <- batchResult{err: err}
+			continue
+		}
+		w.result <- batchResult{ints: ints[:w.quantity:w.quantity]}
+		ints = ints[w.quantity:]
+	}
+}
@@ -0,0 +1,215 @@
+package random
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/koenno/standard-deviation-service/client"
+	"github.com/koenno/standard-deviation-service/random/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestBatcherShouldMergeConcurrentCallsIntoOneRequest(t *testing.T) {
+	// given
+	senderMock := mocks.NewRequestSender(t)
+	parserMock := mocks.NewResponseParser(t)
+	reqFactoryMock := mocks.NewRequestFactory(t)
+	sut := NewBatcher(NewRandom(senderMock, parserMock, reqFactoryMock), BatchSettings{Window: 50 * time.Millisecond, MaxQuantity: 100})
+	contentType := "text/plain"
+	response := []byte("")
+
+	req, err := http.NewRequest(http.MethodGet, "some.domain.com", nil)
+	var opts *client.Options
+	reqFactoryMock.EXPECT().NewRequest(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(ctx context.Context, o ...client.Option) {
+		opts = client.NewOptions(o...)
+	}).Return(req, err).Once()
+	senderMock.EXPECT().Send(req).Return(response, contentType, nil).Once()
+	parserMock.EXPECT().ParseIntegers(response, contentType).Return([]int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}, nil).Once()
+
+	// when
+	var mu sync.Mutex
+	var all []int
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ints, err := sut.Integers(context.Background(), 5, 1, 10)
+			assert.NoError(t, err)
+			assert.Len(t, ints, 5)
+			mu.Lock()
+			all = append(all, ints...)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	// then
+	sort.Ints(all)
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}, all)
+	assert.Equal(t, &client.Options{Min: 1, Max: 10, Quantity: 15}, opts)
+}
+
+func TestBatcherShouldNotMergeCallsForDifferentRanges(t *testing.T) {
+	// given
+	senderMock := mocks.NewRequestSender(t)
+	parserMock := mocks.NewResponseParser(t)
+	reqFactoryMock := mocks.NewRequestFactory(t)
+	sut := NewBatcher(NewRandom(senderMock, parserMock, reqFactoryMock), BatchSettings{Window: 10 * time.Millisecond, MaxQuantity: 100})
+	contentType := "text/plain"
+	response := []byte("")
+
+	req, err := http.NewRequest(http.MethodGet, "some.domain.com", nil)
+	var mu sync.Mutex
+	var ranges [][2]int
+	reqFactoryMock.EXPECT().NewRequest(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(ctx context.Context, o ...client.Option) {
+		opts := client.NewOptions(o...)
+		mu.Lock()
+		ranges = append(ranges, [2]int{opts.Min, opts.Max})
+		mu.Unlock()
+	}).Return(req, err).Twice()
+	senderMock.EXPECT().Send(req).Return(response, contentType, nil).Twice()
+	parserMock.EXPECT().ParseIntegers(response, contentType).Return([]int{1, 2}, nil).Twice()
+
+	// when
+	var wg sync.WaitGroup
+	for _, r := range [][2]int{{1, 10}, {-5, 5}} {
+		wg.Add(1)
+		go func(min, max int) {
+			defer wg.Done()
+			ints, err := sut.Integers(context.Background(), 2, min, max)
+			assert.NoError(t, err)
+			assert.Equal(t, []int{1, 2}, ints)
+		}(r[0], r[1])
+	}
+	wg.Wait()
+
+	// then
+	assert.ElementsMatch(t, [][2]int{{1, 10}, {-5, 5}}, ranges)
+}
+
+func TestBatcherShouldSendFullBatchWithoutWaitingForWindow(t *testing.T) {
+	// given
+	senderMock := mocks.NewRequestSender(t)
+	parserMock := mocks.NewResponseParser(t)
+	reqFactoryMock := mocks.NewRequestFactory(t)
+	sut := NewBatcher(NewRandom(senderMock, parserMock, reqFactoryMock), BatchSettings{Window: time.Hour, MaxQuantity: 4})
+	contentType := "text/plain"
+	response := []byte("")
+
+	req, err := http.NewRequest(http.MethodGet, "some.domain.com", nil)
+	reqFactoryMock.EXPECT().NewRequest(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(req, err).Once()
+	senderMock.EXPECT().Send(req).Return(response, contentType, nil).Once()
+	parserMock.EXPECT().ParseIntegers(response, contentType).Return([]int{1, 2, 3, 4}, nil).Once()
+
+	// when
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ints, err := sut.Integers(context.Background(), 2, 1, 10)
+			assert.NoError(t, err)
+			assert.Len(t, ints, 2)
+		}()
+	}
+
+	// then
+	wg.Wait()
+}
+
+func TestBatcherShouldCallUpstreamDirectlyForLargeQuantities(t *testing.T) {
+	// given
+	senderMock := mocks.NewRequestSender(t)
+	parserMock := mocks.NewResponseParser(t)
+	reqFactoryMock := mocks.NewRequestFactory(t)
+	sut := NewBatcher(NewRandom(senderMock, parserMock, reqFactoryMock), BatchSettings{Window: time.Hour, MaxQuantity: 4})
+	contentType := "text/plain"
+	response := []byte("")
+
+	req, err := http.NewRequest(http.MethodGet, "some.domain.com", nil)
+	reqFactoryMock.EXPECT().NewRequest(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(req, err).Once()
+	senderMock.EXPECT().Send(req).Return(response, contentType, nil).Once()
+	parserMock.EXPECT().ParseIntegers(response, contentType).Return([]int{1, 2, 3, 4, 5}, nil).Once()
+
+	// when
+	ints, err := sut.Integers(context.Background(), 5, 1, 10)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, ints)
+}
+
+func TestBatcherShouldReturnErrorToEveryCaller(t *testing.T) {
+	// given
+	senderMock := mocks.NewRequestSender(t)
+	parserMock := mocks.NewResponseParser(t)
+	reqFactoryMock := mocks.NewRequestFactory(t)
+	sut := NewBatcher(NewRandom(senderMock, parserMock, reqFactoryMock), BatchSettings{Window: 20 * time.Millisecond, MaxQuantity: 100})
+
+	req, err := http.NewRequest(http.MethodGet, "some.domain.com", nil)
+	reqFactoryMock.EXPECT().NewRequest(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(req, err).Once()
+	senderMock.EXPECT().Send(req).Return(nil, "", errors.New("failure")).Once()
+
+	// when
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ints, err := sut.Integers(context.Background(), 3, 1, 10)
+
+			// then
+			assert.ErrorIs(t, err, ErrGenerator)
+			assert.Nil(t, ints)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestBatcherShouldReturnErrorWhenUpstreamReturnsTooFewIntegers(t *testing.T) {
+	// given
+	senderMock := mocks.NewRequestSender(t)
+	parserMock := mocks.NewResponseParser(t)
+	reqFactoryMock := mocks.NewRequestFactory(t)
+	sut := NewBatcher(NewRandom(senderMock, parserMock, reqFactoryMock), BatchSettings{Window: time.Millisecond, MaxQuantity: 100})
+	contentType := "text/plain"
+	response := []byte("")
+
+	req, err := http.NewRequest(http.MethodGet, "some.domain.com", nil)
+	reqFactoryMock.EXPECT().NewRequest(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(req, err).Once()
+	senderMock.EXPECT().Send(req).Return(response, contentType, nil).Once()
+	parserMock.EXPECT().ParseIntegers(response, contentType).Return([]int{1}, nil).Once()
+
+	// when
+	ints, err := sut.Integers(context.Background(), 3, 1, 10)
+
+	// then
+	assert.ErrorIs(t, err, ErrItems)
+	assert.Nil(t, ints)
+}
+
+func TestBatcherShouldDropBatchWhenAllCallersGiveUp(t *testing.T) {
+	// given
+	senderMock := mocks.NewRequestSender(t)
+	parserMock := mocks.NewResponseParser(t)
+	reqFactoryMock := mocks.NewRequestFactory(t)
+	sut := NewBatcher(NewRandom(senderMock, parserMock, reqFactoryMock), BatchSettings{Window: time.Hour, MaxQuantity: 100})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	// when
+	ints, err := sut.Integers(ctx, 3, 1, 10)
+
+	// then
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Nil(t, ints)
+	assert.Empty(t, sut.pending)
+	reqFactoryMock.AssertNotCalled(t, "NewRequest")
+}
@@ -0,0 +1,36 @@
+package random
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+)
+
+// Crypto generates integers with the operating system's cryptographically secure source.
+type Crypto struct {
+}
+
+func NewCrypto() Crypto {
+	return Crypto{}
+}
+
+func (c Crypto) Integers(ctx context.Context, quantity, min, max int) ([]int, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrGenerator, err)
+	}
+	if min > max {
+		return nil, fmt.Errorf("%w: min %d greater than max %d", ErrInit, min, max)
+	}
+
+	span := big.NewInt(int64(max) - int64(min) + 1)
+	ints := make([]int, quantity)
+	for i := range ints {
+		n, err := rand.Int(rand.Reader, span)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrGenerator, err)
+		}
+		ints[i] = min + int(n.Int64())
+	}
+	return ints, nil
+}
@@ -0,0 +1,39 @@
+package random
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShouldReturnCryptoIntegersWithinRange(t *testing.T) {
+	// given
+	sut := NewCrypto()
+	quantity, min, max := 1000, -1_000_000_000, 1_000_000_000
+
+	// when
+	ints, err := sut.Integers(context.Background(), quantity, min, max)
+
+	// then
+	assert.NoError(t, err)
+	assert.Len(t, ints, quantity)
+	for _, i := range ints {
+		assert.GreaterOrEqual(t, i, min)
+		assert.LessOrEqual(t, i, max)
+	}
+}
+
+func TestShouldReturnErrorWhenCryptoContextIsDone(t *testing.T) {
+	// given
+	sut := NewCrypto()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// when
+	ints, err := sut.Integers(ctx, 5, 1, 10)
+
+	// then
+	assert.ErrorIs(t, err, ErrGenerator)
+	assert.Zero(t, ints)
+}
@@ -0,0 +1,44 @@
+package random
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+)
+
+// Local generates pseudo-random integers with math/rand/v2 without any network access.
+type Local struct {
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+// NewLocal returns a generator seeded from the runtime's random source.
+func NewLocal() *Local {
+	return NewSeededLocal(rand.Uint64())
+}
+
+// NewSeededLocal returns a generator producing a reproducible sequence for a given seed.
+func NewSeededLocal(seed uint64) *Local {
+	return &Local{
+		rnd: rand.New(rand.NewPCG(seed, seed)),
+	}
+}
+
+func (l *Local) Integers(ctx context.Context, quantity, min, max int) ([]int, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrGenerator, err)
+	}
+	if min > max {
+		return nil, fmt.Errorf("%w: min %d greater than max %d", ErrInit, min, max)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ints := make([]int, quantity)
+	for i := range ints {
+		ints[i] = min + l.rnd.IntN(max-min+1)
+	}
+	return ints, nil
+}
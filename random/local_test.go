@@ -0,0 +1,55 @@
+package random
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShouldReturnLocalIntegersWithinRange(t *testing.T) {
+	// given
+	sut := NewLocal()
+	quantity, min, max := 1000, -3, 3
+
+	// when
+	ints, err := sut.Integers(context.Background(), quantity, min, max)
+
+	// then
+	assert.NoError(t, err)
+	assert.Len(t, ints, quantity)
+	for _, i := range ints {
+		assert.GreaterOrEqual(t, i, min)
+		assert.LessOrEqual(t, i, max)
+	}
+}
+
+func TestShouldReturnSameLocalIntegersForSameSeed(t *testing.T) {
+	// given
+	first := NewSeededLocal(42)
+	second := NewSeededLocal(42)
+
+	// when
+	firstInts, firstErr := first.Integers(context.Background(), 20, 1, 100)
+	secondInts, secondErr := second.Integers(context.Background(), 20, 1, 100)
+
+	// then
+	assert.NoError(t, firstErr)
+	assert.NoError(t, secondErr)
+	assert.Equal(t, firstInts, secondInts)
+}
+
+func TestShouldReturnErrorWhenLocalContextIsDone(t *testing.T) {
+	// given
+	sut := NewLocal()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// when
+	ints, err := sut.Integers(ctx, 5, 1, 10)
+
+	// then
+	assert.ErrorIs(t, err, ErrGenerator)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Zero(t, ints)
+}
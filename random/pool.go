@@ -0,0 +1,176 @@
+package random
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/exp/slog"
+)
+
+//go:generate mockery --name=Generator --case underscore --with-expecter
+type Generator interface {
+	Integers(ctx context.Context, quantity, min, max int) ([]int, error)
+}
+
+// PoolSettings configure the buffer of a Pool. Only integers within
+// [Min, Max] are buffered, requests for other ranges go to the upstream.
+type PoolSettings struct {
+	Size          int
+	LowWater      int
+	BatchSize     int
+	Min           int
+	Max           int
+	RefillBackoff time.Duration
+}
+
+func DefaultPoolSettings() PoolSettings {
+	return PoolSettings{
+		Size:          50_000,
+		LowWater:      10_000,
+		BatchSize:     MaxQuantityPerRequest,
+		Min:           1,
+		Max:           10,
+		RefillBackoff: time.Second,
+	}
+}
+
+// PoolStats is a snapshot of the pool usage.
+type PoolStats struct {
+	Size     int     `json:"size"`
+	Buffered int     `json:"buffered"`
+	Hits     int64   `json:"hits"`
+	Misses   int64   `json:"misses"`
+	HitRatio float64 `json:"hit_ratio"`
+}
+
+// Pool serves integers from a buffer refilled in the background in large
+// batches, so callers do not wait for the upstream generator. When the buffer
+// cannot satisfy a call, the upstream generator is called directly.
+type Pool struct {
+	upstream Generator
+	settings PoolSettings
+	refill   chan struct{}
+
+	mu  sync.Mutex
+	buf []int
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+func NewPool(upstream Generator, settings PoolSettings) *Pool {
+	return &Pool{
+		upstream: upstream,
+		settings: settings,
+		refill:   make(chan struct{}, 1),
+		buf:      make([]int, 0, settings.Size),
+	}
+}
+
+// Run keeps the pool filled until the context is done.
+func (p *Pool) Run(ctx context.Context) {
+	for {
+		err := p.Fill(ctx)
+		if err != nil {
+			slog.Error("failed to refill the integer pool", "error", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(p.settings.RefillBackoff):
+			}
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.refill:
+		}
+	}
+}
+
+// Fill requests batches from the upstream generator until the pool is full.
+func (p *Pool) Fill(ctx context.Context) error {
+	for {
+		missing := p.settings.Size - p.Buffered()
+		if missing <= 0 {
+			return nil
+		}
+		batch := min(missing, p.settings.BatchSize)
+		ints, err := p.upstream.Integers(ctx, batch, p.settings.Min, p.settings.Max)
+		if err != nil {
+			return fmt.Errorf("failed to fetch a batch of %d integers: %w", batch, err)
+		}
+		if len(ints) == 0 {
+			// the pool would otherwise ask for the same batch forever
+			return fmt.Errorf("%w: got no integers in a batch of %d", ErrItems, batch)
+		}
+
+		p.mu.Lock()
+		p.buf = append(p.buf, ints[:min(len(ints), p.settings.Size-len(p.buf))]...)
+		p.mu.Unlock()
+	}
+}
+
+func (p *Pool) Integers(ctx context.Context, quantity, min, max int) ([]int, error) {
+	if min == p.settings.Min && max == p.settings.Max {
+		ints, ok := p.take(quantity)
+		if ok {
+			p.hits.Add(1)
+			return ints, nil
+		}
+	}
+	p.misses.Add(1)
+	return p.upstream.Integers(ctx, quantity, min, max)
+}
+
+func (p *Pool) Buffered() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.buf)
+}
+
+func (p *Pool) Stats() PoolStats {
+	stats := PoolStats{
+		Size:     p.settings.Size,
+		Buffered: p.Buffered(),
+		Hits:     p.hits.Load(),
+		Misses:   p.misses.Load(),
+	}
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRatio = float64(stats.Hits) / float64(total)
+	}
+	return stats
+}
+
+func (p *Pool) Status() any {
+	return p.Stats()
+}
+
+func (p *Pool) take(quantity int) ([]int, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	defer p.requestRefill()
+
+	if len(p.buf) < quantity {
+		return nil, false
+	}
+	rest := len(p.buf) - quantity
+	ints := make([]int, quantity)
+	copy(ints, p.buf[rest:])
+	p.buf = p.buf[:rest]
+	return ints, true
+}
+
+// requestRefill wakes Run up once the buffer drops below the low-water mark.
+func (p *Pool) requestRefill() {
+	if len(p.buf) >= p.settings.LowWater {
+		return
+	}
+	select {
+	case p.refill <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,168 @@
+package random
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/koenno/standard-deviation-service/random/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func testPoolSettings() PoolSettings {
+	return PoolSettings{
+		Size:          10,
+		LowWater:      4,
+		BatchSize:     6,
+		Min:           1,
+		Max:           10,
+		RefillBackoff: time.Millisecond,
+	}
+}
+
+func sequence(from, quantity int) []int {
+	ints := make([]int, quantity)
+	for i := range ints {
+		ints[i] = from + i
+	}
+	return ints
+}
+
+func TestShouldFillPoolInBatches(t *testing.T) {
+	// given
+	generatorMock := mocks.NewGenerator(t)
+	sut := NewPool(generatorMock, testPoolSettings())
+
+	generatorMock.EXPECT().Integers(mock.Anything, 6, 1, 10).Return(sequence(1, 6), nil).Once()
+	generatorMock.EXPECT().Integers(mock.Anything, 4, 1, 10).Return(sequence(7, 4), nil).Once()
+
+	// when
+	err := sut.Fill(context.Background())
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, 10, sut.Buffered())
+}
+
+func TestShouldServeIntegersFromPool(t *testing.T) {
+	// given
+	generatorMock := mocks.NewGenerator(t)
+	sut := NewPool(generatorMock, testPoolSettings())
+
+	generatorMock.EXPECT().Integers(mock.Anything, 6, 1, 10).Return(sequence(1, 6), nil).Once()
+	generatorMock.EXPECT().Integers(mock.Anything, 4, 1, 10).Return(sequence(7, 4), nil).Once()
+	assert.NoError(t, sut.Fill(context.Background()))
+
+	// when
+	ints, err := sut.Integers(context.Background(), 3, 1, 10)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, []int{8, 9, 10}, ints)
+	assert.Equal(t, 7, sut.Buffered())
+	assert.Equal(t, PoolStats{Size: 10, Buffered: 7, Hits: 1, Misses: 0, HitRatio: 1}, sut.Stats())
+}
+
+func TestShouldFallBackToUpstreamWhenPoolIsDrained(t *testing.T) {
+	// given
+	generatorMock := mocks.NewGenerator(t)
+	sut := NewPool(generatorMock, testPoolSettings())
+
+	generatorMock.EXPECT().Integers(mock.Anything, 3, 1, 10).Return([]int{5, 5, 5}, nil).Once()
+
+	// when
+	ints, err := sut.Integers(context.Background(), 3, 1, 10)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, []int{5, 5, 5}, ints)
+	assert.Equal(t, PoolStats{Size: 10, Buffered: 0, Hits: 0, Misses: 1, HitRatio: 0}, sut.Stats())
+}
+
+func TestShouldCallUpstreamForOtherRanges(t *testing.T) {
+	// given
+	generatorMock := mocks.NewGenerator(t)
+	sut := NewPool(generatorMock, testPoolSettings())
+
+	generatorMock.EXPECT().Integers(mock.Anything, 6, 1, 10).Return(sequence(1, 6), nil).Once()
+	generatorMock.EXPECT().Integers(mock.Anything, 4, 1, 10).Return(sequence(7, 4), nil).Once()
+	assert.NoError(t, sut.Fill(context.Background()))
+	generatorMock.EXPECT().Integers(mock.Anything, 2, -5, 5).Return([]int{-5, 5}, nil).Once()
+
+	// when
+	ints, err := sut.Integers(context.Background(), 2, -5, 5)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, []int{-5, 5}, ints)
+	assert.Equal(t, 10, sut.Buffered())
+}
+
+func TestShouldReturnUpstreamErrorOnMiss(t *testing.T) {
+	// given
+	generatorMock := mocks.NewGenerator(t)
+	sut := NewPool(generatorMock, testPoolSettings())
+
+	generatorMock.EXPECT().Integers(mock.Anything, 3, 1, 10).Return(nil, ErrGenerator).Once()
+
+	// when
+	ints, err := sut.Integers(context.Background(), 3, 1, 10)
+
+	// then
+	assert.ErrorIs(t, err, ErrGenerator)
+	assert.Zero(t, ints)
+}
+
+func TestShouldReturnErrorWhenFillFails(t *testing.T) {
+	// given
+	generatorMock := mocks.NewGenerator(t)
+	sut := NewPool(generatorMock, testPoolSettings())
+
+	generatorMock.EXPECT().Integers(mock.Anything, 6, 1, 10).Return(nil, errors.New("failure")).Once()
+
+	// when
+	err := sut.Fill(context.Background())
+
+	// then
+	assert.Error(t, err)
+	assert.Equal(t, 0, sut.Buffered())
+}
+
+func TestShouldReturnErrorWhenBatchIsEmpty(t *testing.T) {
+	// given
+	generatorMock := mocks.NewGenerator(t)
+	sut := NewPool(generatorMock, testPoolSettings())
+
+	generatorMock.EXPECT().Integers(mock.Anything, 6, 1, 10).Return([]int{}, nil).Once()
+
+	// when
+	err := sut.Fill(context.Background())
+
+	// then
+	assert.ErrorIs(t, err, ErrItems)
+	assert.Equal(t, 0, sut.Buffered())
+}
+
+func TestShouldRefillPoolBelowLowWaterMark(t *testing.T) {
+	// given
+	generatorMock := mocks.NewGenerator(t)
+	sut := NewPool(generatorMock, testPoolSettings())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	generatorMock.EXPECT().Integers(mock.Anything, 6, 1, 10).Return(sequence(1, 6), nil)
+	generatorMock.EXPECT().Integers(mock.Anything, 4, 1, 10).Return(sequence(7, 4), nil)
+	generatorMock.EXPECT().Integers(mock.Anything, 1, 1, 10).Return(sequence(1, 1), nil)
+	go sut.Run(ctx)
+	assert.Eventually(t, func() bool { return sut.Buffered() == 10 }, time.Second, time.Millisecond)
+
+	// when
+	ints, err := sut.Integers(context.Background(), 7, 1, 10)
+
+	// then
+	assert.NoError(t, err)
+	assert.Len(t, ints, 7)
+	assert.Eventually(t, func() bool { return sut.Buffered() == 10 }, time.Second, time.Millisecond)
+}
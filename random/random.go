@@ -7,8 +7,17 @@ import (
 	"net/http"
 
 	"github.com/koenno/standard-deviation-service/client"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+const tracerName = "github.com/koenno/standard-deviation-service/random"
+
+// MaxQuantityPerRequest is the largest number of integers random.org returns for a single request.
+const MaxQuantityPerRequest = 10_000
+
 var (
 	ErrInit      = errors.New("failed to initialize random generator")
 	ErrGenerator = errors.New("random generator failure")
@@ -44,20 +53,56 @@ func NewRandom(reqSender RequestSender, respParser ResponseParser, reqFactory Re
 	}
 }
 
-func (r Random) Integers(ctx context.Context, quantity int) ([]int, error) {
-	req, err := r.reqFactory.NewRequest(ctx, client.WithQuantity(quantity))
+// Integers splits quantities exceeding MaxQuantityPerRequest into multiple requests.
+func (r Random) Integers(ctx context.Context, quantity, min, max int) ([]int, error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "random.Integers", trace.WithAttributes(
+		attribute.Int("random.quantity", quantity),
+		attribute.Int("random.min", min),
+		attribute.Int("random.max", max),
+	))
+	defer span.End()
+
+	// quantity may be unbounded when the limits are disabled, so the result
+	// grows with the chunks received instead of being allocated upfront
+	capacity := quantity
+	if capacity > MaxQuantityPerRequest {
+		capacity = MaxQuantityPerRequest
+	}
+	if capacity < 0 {
+		capacity = 0
+	}
+	ints := make([]int, 0, capacity)
+	for remaining := quantity; remaining > 0; {
+		chunk := remaining
+		if chunk > MaxQuantityPerRequest {
+			chunk = MaxQuantityPerRequest
+		}
+		part, err := r.integers(ctx, chunk, min, max)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return nil, err
+		}
+		ints = append(ints, part...)
+		remaining -= chunk
+	}
+	return ints, nil
+}
+
+func (r Random) integers(ctx context.Context, quantity, min, max int) ([]int, error) {
+	req, err := r.reqFactory.NewRequest(ctx, client.WithQuantity(quantity), client.WithMin(min), client.WithMax(max))
 	if err != nil {
-		return nil, fmt.Errorf("%w: %v", ErrInit, err)
+		return nil, fmt.Errorf("%w: %w", ErrInit, err)
 	}
 
 	bb, contentType, err := r.reqSender.Send(req)
 	if err != nil {
-		return nil, fmt.Errorf("%w: %v", ErrGenerator, err)
+		return nil, fmt.Errorf("%w: %w", ErrGenerator, err)
 	}
 
 	ints, err := r.respParser.ParseIntegers(bb, contentType)
 	if err != nil {
-		return nil, fmt.Errorf("%w (integers): %v", ErrItems, err)
+		return nil, fmt.Errorf("%w (integers): %w", ErrItems, err)
 	}
 
 	return ints, nil
@@ -3,9 +3,11 @@ package random
 import (
 	"context"
 	"errors"
+	"math"
 	"net/http"
 	"testing"
 
+	"github.com/koenno/standard-deviation-service/client"
 	"github.com/koenno/standard-deviation-service/random/mocks"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -20,11 +22,11 @@ func TestShouldReturnErrorWhenRequestFails(t *testing.T) {
 	quantity := 4
 
 	req, err := http.NewRequest(http.MethodGet, "some.domain.com", nil)
-	reqFactoryMock.EXPECT().NewRequest(mock.Anything, mock.Anything).Return(req, err).Once()
+	reqFactoryMock.EXPECT().NewRequest(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(req, err).Once()
 	senderMock.EXPECT().Send(req).Return(nil, "", errors.New("failure")).Once()
 
 	// when
-	ints, err := sut.Integers(context.Background(), quantity)
+	ints, err := sut.Integers(context.Background(), quantity, 1, 10)
 
 	// then
 	assert.ErrorIs(t, err, ErrGenerator)
@@ -32,6 +34,25 @@ func TestShouldReturnErrorWhenRequestFails(t *testing.T) {
 	parserMock.AssertNotCalled(t, "ParseIntegers")
 }
 
+func TestShouldNotAllocateHugeQuantityUpfront(t *testing.T) {
+	// given
+	senderMock := mocks.NewRequestSender(t)
+	parserMock := mocks.NewResponseParser(t)
+	reqFactoryMock := mocks.NewRequestFactory(t)
+	sut := NewRandom(senderMock, parserMock, reqFactoryMock)
+
+	req, err := http.NewRequest(http.MethodGet, "some.domain.com", nil)
+	reqFactoryMock.EXPECT().NewRequest(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(req, err).Once()
+	senderMock.EXPECT().Send(req).Return(nil, "", errors.New("failure")).Once()
+
+	// when
+	ints, err := sut.Integers(context.Background(), math.MaxInt, 1, 10)
+
+	// then
+	assert.ErrorIs(t, err, ErrGenerator)
+	assert.Zero(t, ints)
+}
+
 func TestShouldReturnErrorWhenParsingResponseFails(t *testing.T) {
 	// given
 	senderMock := mocks.NewRequestSender(t)
@@ -43,12 +64,12 @@ func TestShouldReturnErrorWhenParsingResponseFails(t *testing.T) {
 	response := []byte("")
 
 	req, err := http.NewRequest(http.MethodGet, "some.domain.com", nil)
-	reqFactoryMock.EXPECT().NewRequest(mock.Anything, mock.Anything).Return(req, err).Once()
+	reqFactoryMock.EXPECT().NewRequest(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(req, err).Once()
 	senderMock.EXPECT().Send(mock.AnythingOfType("*http.Request")).Return(response, contentType, nil).Once()
 	parserMock.EXPECT().ParseIntegers(mock.Anything, mock.Anything).Return(nil, errors.New("failure")).Once()
 
 	// when
-	ints, err := sut.Integers(context.Background(), quantity)
+	ints, err := sut.Integers(context.Background(), quantity, 1, 10)
 
 	// then
 	assert.ErrorIs(t, err, ErrItems)
@@ -62,19 +83,52 @@ func TestShouldReturnGeneratedIntegers(t *testing.T) {
 	reqFactoryMock := mocks.NewRequestFactory(t)
 	sut := NewRandom(senderMock, parserMock, reqFactoryMock)
 	quantity := 3
+	min, max := -5, 7
 	contentType := "text/plain"
 	response := []byte("")
 	expectedInts := []int{1, 7, 4}
 
 	req, err := http.NewRequest(http.MethodGet, "some.domain.com", nil)
-	reqFactoryMock.EXPECT().NewRequest(mock.Anything, mock.Anything).Return(req, err).Once()
+	var opts *client.Options
+	reqFactoryMock.EXPECT().NewRequest(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(ctx context.Context, o ...client.Option) {
+		opts = client.NewOptions(o...)
+	}).Return(req, err).Once()
 	senderMock.EXPECT().Send(mock.AnythingOfType("*http.Request")).Return(response, contentType, nil).Once()
 	parserMock.EXPECT().ParseIntegers(response, contentType).Return(expectedInts, nil).Once()
 
 	// when
-	ints, err := sut.Integers(context.Background(), quantity)
+	ints, err := sut.Integers(context.Background(), quantity, min, max)
 
 	// then
 	assert.NoError(t, err)
 	assert.Equal(t, expectedInts, ints)
+	assert.Equal(t, &client.Options{Min: min, Max: max, Quantity: quantity}, opts)
+}
+
+func TestShouldSplitLargeQuantityIntoMultipleRequests(t *testing.T) {
+	// given
+	senderMock := mocks.NewRequestSender(t)
+	parserMock := mocks.NewResponseParser(t)
+	reqFactoryMock := mocks.NewRequestFactory(t)
+	sut := NewRandom(senderMock, parserMock, reqFactoryMock)
+	quantity := 2*MaxQuantityPerRequest + 5
+	contentType := "text/plain"
+	response := []byte("")
+
+	req, err := http.NewRequest(http.MethodGet, "some.domain.com", nil)
+	var quantities []int
+	reqFactoryMock.EXPECT().NewRequest(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(ctx context.Context, o ...client.Option) {
+		quantities = append(quantities, client.NewOptions(o...).Quantity)
+	}).Return(req, err).Times(3)
+	senderMock.EXPECT().Send(req).Return(response, contentType, nil).Times(3)
+	parserMock.EXPECT().ParseIntegers(response, contentType).Return(make([]int, MaxQuantityPerRequest), nil).Twice()
+	parserMock.EXPECT().ParseIntegers(response, contentType).Return(make([]int, 5), nil).Once()
+
+	// when
+	ints, err := sut.Integers(context.Background(), quantity, 1, 10)
+
+	// then
+	assert.NoError(t, err)
+	assert.Len(t, ints, quantity)
+	assert.Equal(t, []int{MaxQuantityPerRequest, MaxQuantityPerRequest, 5}, quantities)
 }
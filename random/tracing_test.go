@@ -0,0 +1,58 @@
+package random
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/koenno/standard-deviation-service/client"
+	"github.com/koenno/standard-deviation-service/random/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func TestShouldTraceIntegersCall(t *testing.T) {
+	// given
+	recorder := recordSpans(t)
+	senderMock := mocks.NewRequestSender(t)
+	parserMock := mocks.NewResponseParser(t)
+	reqFactoryMock := mocks.NewRequestFactory(t)
+	sut := NewRandom(senderMock, parserMock, reqFactoryMock)
+
+	req, _ := http.NewRequest(http.MethodGet, "some.domain.com", nil)
+	var factoryCtx context.Context
+	reqFactoryMock.EXPECT().NewRequest(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(ctx context.Context, _ ...client.Option) {
+		factoryCtx = ctx
+	}).Return(req, nil).Once()
+	senderMock.EXPECT().Send(req).Return(nil, "", errors.New("failure")).Once()
+
+	// when
+	_, err := sut.Integers(context.Background(), 3, 1, 6)
+
+	// then
+	assert.ErrorIs(t, err, ErrGenerator)
+	spans := recorder.Ended()
+	assert.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "random.Integers", span.Name())
+	assert.Equal(t, codes.Error, span.Status().Code)
+	assert.Contains(t, span.Attributes(), attribute.Int("random.quantity", 3))
+	assert.Contains(t, span.Attributes(), attribute.Int("random.min", 1))
+	assert.Contains(t, span.Attributes(), attribute.Int("random.max", 6))
+	assert.Equal(t, span.SpanContext().SpanID(), trace.SpanContextFromContext(factoryCtx).SpanID())
+}
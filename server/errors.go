@@ -0,0 +1,155 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/koenno/standard-deviation-service/breaker"
+	"github.com/koenno/standard-deviation-service/client"
+	"github.com/koenno/standard-deviation-service/client/randomorg"
+	"github.com/koenno/standard-deviation-service/random"
+	"github.com/koenno/standard-deviation-service/store"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/exp/slog"
+)
+
+const (
+	CodeInvalidParameter    = "invalid_parameter"
+	CodeInvalidBody         = "invalid_body"
+	CodeLimitExceeded       = "limit_exceeded"
+	CodeUpstreamTimeout     = "upstream_timeout"
+	CodeUpstreamUnavailable = "upstream_unavailable"
+	CodeCircuitOpen         = "circuit_open"
+	CodeQuotaExceeded       = "quota_exceeded"
+	CodeUpstreamResponse    = "upstream_bad_response"
+	CodeUpstreamItems       = "upstream_bad_items"
+	CodeGenerator           = "generator_failure"
+	CodeGeneratorInit       = "generator_init_failure"
+	CodeJobNotFound         = "job_not_found"
+	CodeJobQueueFull        = "job_queue_full"
+	CodeJobCapacity         = "job_capacity_exceeded"
+	CodeResultNotFound      = "result_not_found"
+	CodeRequestIDConflict   = "request_id_conflict"
+	CodeInternal            = "internal_error"
+	CodeClientClosed        = "client_closed_request"
+	CodeShuttingDown        = "shutting_down"
+)
+
+// StatusClientClosedRequest answers requests abandoned by their client, as
+// nginx does, so they are neither logged nor counted as server errors.
+const StatusClientClosedRequest = 499
+
+// ErrorResponse is the document written for every failed request.
+type ErrorResponse struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id,omitempty"`
+	Parameter string `json:"parameter,omitempty"`
+	// Fields lists every invalid field of a request body.
+	Fields []FieldError `json:"fields,omitempty"`
+}
+
+// FieldError describes a single invalid field of a request body.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ParamError reports an invalid query parameter.
+type ParamError struct {
+	Param string
+	Err   error
+}
+
+func (e *ParamError) Error() string {
+	return e.Param + " " + e.Err.Error()
+}
+
+func (e *ParamError) Unwrap() error {
+	return e.Err
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	status, code := classifyError(err)
+	payload := ErrorResponse{
+		Code:      code,
+		Message:   err.Error(),
+		RequestID: middleware.GetReqID(r.Context()),
+	}
+	var specErr *SpecError
+	var paramErr *ParamError
+	switch {
+	case errors.As(err, &specErr):
+		for _, fieldErr := range specErr.Errors {
+			payload.Fields = append(payload.Fields, FieldError{Field: fieldErr.Param, Message: fieldErr.Err.Error()})
+		}
+	case errors.As(err, &paramErr):
+		payload.Parameter = paramErr.Param
+	}
+
+	if status >= http.StatusInternalServerError {
+		trace.SpanFromContext(r.Context()).RecordError(err)
+		slog.Error("request failed", "timestamp", time.Now(), "status", status, "error", err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err = json.NewEncoder(w).Encode(payload)
+	if err != nil {
+		slog.Error("failed to encode the error payload", "error", err)
+	}
+}
+
+func classifyError(err error) (int, string) {
+	var paramErr *ParamError
+	var netErr net.Error
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.Is(err, ErrJobNotFound):
+		return http.StatusNotFound, CodeJobNotFound
+	case errors.Is(err, store.ErrNotFound):
+		return http.StatusNotFound, CodeResultNotFound
+	case errors.Is(err, store.ErrExists):
+		return http.StatusConflict, CodeRequestIDConflict
+	case errors.Is(err, ErrJobQueueFull):
+		return http.StatusTooManyRequests, CodeJobQueueFull
+	case errors.Is(err, ErrJobCapacity):
+		return http.StatusServiceUnavailable, CodeJobCapacity
+	case errors.As(err, &maxBytesErr):
+		return http.StatusRequestEntityTooLarge, CodeLimitExceeded
+	case errors.Is(err, ErrInvalidBody):
+		return http.StatusBadRequest, CodeInvalidBody
+	case errors.Is(err, ErrParamTooLarge), errors.Is(err, ErrParamTotalTooLarge), errors.Is(err, ErrParamLengthsTooLarge):
+		return http.StatusUnprocessableEntity, CodeLimitExceeded
+	case errors.As(err, &paramErr):
+		return http.StatusBadRequest, CodeInvalidParameter
+	case errors.Is(err, breaker.ErrOpen):
+		return http.StatusServiceUnavailable, CodeCircuitOpen
+	case errors.Is(err, randomorg.ErrQuotaExceeded):
+		return http.StatusServiceUnavailable, CodeQuotaExceeded
+	case errors.Is(err, ErrShuttingDown):
+		return http.StatusServiceUnavailable, CodeShuttingDown
+	case errors.Is(err, context.Canceled):
+		return StatusClientClosedRequest, CodeClientClosed
+	case errors.Is(err, context.DeadlineExceeded),
+		errors.As(err, &netErr) && netErr.Timeout():
+		return http.StatusGatewayTimeout, CodeUpstreamTimeout
+	case errors.Is(err, client.ErrSendRequest):
+		return http.StatusServiceUnavailable, CodeUpstreamUnavailable
+	case errors.Is(err, client.ErrResponse):
+		return http.StatusBadGateway, CodeUpstreamResponse
+	case errors.Is(err, random.ErrItems):
+		return http.StatusBadGateway, CodeUpstreamItems
+	case errors.Is(err, random.ErrGenerator):
+		return http.StatusBadGateway, CodeGenerator
+	case errors.Is(err, random.ErrInit):
+		return http.StatusInternalServerError, CodeGeneratorInit
+	default:
+		return http.StatusInternalServerError, CodeInternal
+	}
+}
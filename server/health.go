@@ -0,0 +1,62 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"golang.org/x/exp/slog"
+)
+
+const (
+	HealthOK          = "ok"
+	HealthUnavailable = "unavailable"
+)
+
+// HealthResponse is returned by /healthz and /readyz. Checks holds the result
+// of every readiness check, either ok or the reason the check failed.
+type HealthResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// Healthz reports that the process is up and serving requests.
+func (s *RandomServer) Healthz(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, http.StatusOK, HealthResponse{Status: HealthOK})
+}
+
+// Readyz reports whether the server should receive traffic: it is not shutting
+// down and none of the readiness checks fails.
+func (s *RandomServer) Readyz(w http.ResponseWriter, r *http.Request) {
+	payload := HealthResponse{
+		Status: HealthOK,
+		Checks: make(map[string]string, len(s.checks)+1),
+	}
+	if s.draining.Load() {
+		payload.Status = HealthUnavailable
+		payload.Checks["shutdown"] = "server is shutting down"
+	}
+	for name, checker := range s.checks {
+		err := checker.Ready()
+		if err != nil {
+			payload.Status = HealthUnavailable
+			payload.Checks[name] = err.Error()
+			continue
+		}
+		payload.Checks[name] = HealthOK
+	}
+
+	status := http.StatusOK
+	if payload.Status != HealthOK {
+		status = http.StatusServiceUnavailable
+	}
+	writeHealth(w, status, payload)
+}
+
+func writeHealth(w http.ResponseWriter, status int, payload HealthResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(payload)
+	if err != nil {
+		slog.Error("failed to encode the payload", "error", err)
+	}
+}
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/koenno/standard-deviation-service/breaker"
+	"github.com/koenno/standard-deviation-service/server/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func getHealth(t *testing.T, sut *RandomServer, URL string) (int, HealthResponse) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	sut.srv.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, URL, nil))
+	res := w.Result()
+	defer res.Body.Close()
+	var payload HealthResponse
+	err := json.NewDecoder(res.Body).Decode(&payload)
+	assert.NoError(t, err)
+	assert.Equal(t, "application/json", res.Header.Get("Content-Type"))
+	return res.StatusCode, payload
+}
+
+func TestShouldReportLiveness(t *testing.T) {
+	// given
+	port := 8080
+	checkerMock := mocks.NewReadinessChecker(t)
+	sut := NewRandomServer(mocks.NewRandomIntegerGenerator(t), mocks.NewStdDevCalculator(t), port, WithReadinessCheck("breaker", checkerMock))
+
+	// when
+	status, payload := getHealth(t, sut, "/healthz")
+
+	// then
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, HealthResponse{Status: HealthOK}, payload)
+	checkerMock.AssertNotCalled(t, "Ready")
+}
+
+func TestShouldReportReadiness(t *testing.T) {
+	tests := []struct {
+		name            string
+		breakerErr      error
+		quotaErr        error
+		generatorErr    error
+		expectedStatus  int
+		expectedPayload HealthResponse
+	}{
+		{
+			name:           "all checks pass",
+			expectedStatus: http.StatusOK,
+			expectedPayload: HealthResponse{
+				Status: HealthOK,
+				Checks: map[string]string{"breaker": HealthOK, "quota": HealthOK, "generator": HealthOK},
+			},
+		},
+		{
+			name:           "circuit open",
+			breakerErr:     breaker.ErrOpen,
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedPayload: HealthResponse{
+				Status: HealthUnavailable,
+				Checks: map[string]string{"breaker": breaker.ErrOpen.Error(), "quota": HealthOK, "generator": HealthOK},
+			},
+		},
+		{
+			name:           "generator unreachable",
+			generatorErr:   errors.New("random.org is unreachable"),
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedPayload: HealthResponse{
+				Status: HealthUnavailable,
+				Checks: map[string]string{"breaker": HealthOK, "quota": HealthOK, "generator": "random.org is unreachable"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// given
+			port := 8080
+			breakerMock := mocks.NewReadinessChecker(t)
+			quotaMock := mocks.NewReadinessChecker(t)
+			sut := NewRandomServer(mocks.NewRandomIntegerGenerator(t), mocks.NewStdDevCalculator(t), port,
+				WithReadinessCheck("breaker", breakerMock),
+				WithReadinessCheck("quota", quotaMock),
+				WithReadinessCheck("generator", ReadinessCheckerFunc(func() error { return test.generatorErr })),
+			)
+
+			breakerMock.EXPECT().Ready().Return(test.breakerErr).Once()
+			quotaMock.EXPECT().Ready().Return(test.quotaErr).Once()
+
+			// when
+			status, payload := getHealth(t, sut, "/readyz")
+
+			// then
+			assert.Equal(t, test.expectedStatus, status)
+			assert.Equal(t, test.expectedPayload, payload)
+		})
+	}
+}
+
+func TestShouldFailReadinessWhenStopping(t *testing.T) {
+	// given
+	port := 8080
+	sut := NewRandomServer(mocks.NewRandomIntegerGenerator(t), mocks.NewStdDevCalculator(t), port)
+
+	// when
+	sut.Stop(context.Background())
+	status, payload := getHealth(t, sut, "/readyz")
+
+	// then
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, HealthUnavailable, payload.Status)
+	assert.Equal(t, map[string]string{"shutdown": "server is shutting down"}, payload.Checks)
+}
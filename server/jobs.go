@@ -0,0 +1,424 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/koenno/standard-deviation-service/service"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/exp/slog"
+)
+
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	JobFailed    JobStatus = "failed"
+	JobCancelled JobStatus = "cancelled"
+)
+
+var (
+	ErrJobNotFound  = errors.New("job not found")
+	ErrJobQueueFull = errors.New("job queue is full")
+	ErrJobCapacity  = errors.New("jobs hold too many integers")
+)
+
+// JobSettings configures the background execution of POST /jobs requests.
+// Limits replace the server limits for jobs, which are not bound by the
+// request timeout.
+type JobSettings struct {
+	// Workers is the number of jobs run concurrently.
+	Workers int
+	// QueueSize is the number of jobs waiting for a worker, beyond which
+	// new jobs are rejected.
+	QueueSize int
+	// TTL is how long a finished job is kept.
+	TTL time.Duration
+	// MaxRetained is the number of integers the kept jobs may hold, beyond
+	// which new jobs are rejected. A zero value disables the limit.
+	MaxRetained int
+	Limits      Limits
+}
+
+func DefaultJobSettings() JobSettings {
+	return JobSettings{
+		Workers:     4,
+		QueueSize:   100,
+		TTL:         15 * time.Minute,
+		MaxRetained: 20_000_000,
+		Limits: Limits{
+			MaxRequests:    10_000,
+			MaxLength:      10_000,
+			MaxTotal:       10_000_000,
+			MaxConcurrency: 10,
+		},
+	}
+}
+
+// JobProgress counts the sets calculated so far.
+type JobProgress struct {
+	Completed int `json:"completed"`
+	Total     int `json:"total"`
+}
+
+// JobResponse describes a job. Sets holds the results calculated so far,
+// Combined is set once the job succeeded and Error once it failed.
+type JobResponse struct {
+	ID         string                 `json:"id"`
+	Status     JobStatus              `json:"status"`
+	Progress   JobProgress            `json:"progress"`
+	CreatedAt  time.Time              `json:"created_at"`
+	FinishedAt *time.Time             `json:"finished_at,omitempty"`
+	ExpiresAt  *time.Time             `json:"expires_at,omitempty"`
+	Sets       []service.StdDevResult `json:"sets"`
+	Combined   *service.StdDevResult  `json:"combined,omitempty"`
+	Error      *ErrorResponse         `json:"error,omitempty"`
+}
+
+type job struct {
+	id       string
+	params   meanParams
+	link     trace.Link
+	status   JobStatus
+	progress JobProgress
+	sets     []service.StdDevResult
+	combined *service.StdDevResult
+	err      error
+	created  time.Time
+	finished time.Time
+	cancel   context.CancelFunc
+	// keepData keeps the integers of every set, which only jobs asking for
+	// them or their median do.
+	keepData bool
+	// retained is the number of integers the job may hold.
+	retained int
+}
+
+type meanFunc func(ctx context.Context, params meanParams, emit func(service.StdDevResult) error) error
+
+// jobQueue runs jobs on a bounded number of workers and forgets them TTL
+// after they finished. Expired jobs are removed whenever the queue is used
+// and every TTL, so an idle queue does not hold on to their results.
+type jobQueue struct {
+	settings JobSettings
+	mean     meanFunc
+	now      func() time.Time
+	// wake signals a worker that jobs are pending.
+	wake chan struct{}
+
+	mu       sync.Mutex
+	jobs     map[string]*job
+	pending  []*job
+	retained int
+}
+
+func newJobQueue(settings JobSettings, mean meanFunc) *jobQueue {
+	return &jobQueue{
+		settings: settings,
+		mean:     mean,
+		now:      time.Now,
+		wake:     make(chan struct{}, 1),
+		jobs:     make(map[string]*job),
+	}
+}
+
+// start runs the workers and the sweep of expired jobs until ctx is done.
+// Running jobs are cancelled with ctx.
+func (q *jobQueue) start(ctx context.Context) {
+	for i := 0; i < q.settings.Workers; i++ {
+		go q.work(ctx)
+	}
+	if q.settings.TTL > 0 {
+		go q.sweep(ctx, q.settings.TTL)
+	}
+}
+
+func (q *jobQueue) sweep(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			q.mu.Lock()
+			q.expire()
+			q.mu.Unlock()
+		}
+	}
+}
+
+func (q *jobQueue) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-q.wake:
+		}
+		for j := q.next(); j != nil && ctx.Err() == nil; j = q.next() {
+			q.execute(ctx, j)
+		}
+	}
+}
+
+// next takes the oldest pending job and wakes another worker for the rest.
+func (q *jobQueue) next() *job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.pending) == 0 {
+		return nil
+	}
+	j := q.pending[0]
+	q.pending = q.pending[1:]
+	if len(q.pending) > 0 {
+		q.signal()
+	}
+	return j
+}
+
+// signal wakes a worker unless one is about to wake already.
+func (q *jobQueue) signal() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (q *jobQueue) submit(ctx context.Context, params meanParams) (JobResponse, error) {
+	id, err := newJobID()
+	if err != nil {
+		return JobResponse{}, err
+	}
+	j := &job{
+		id:       id,
+		params:   params,
+		link:     trace.LinkFromContext(ctx),
+		status:   JobQueued,
+		progress: JobProgress{Total: len(params.setList())},
+		keepData: slices.Contains(params.fields, service.FieldData) || slices.Contains(params.fields, service.FieldMedian),
+	}
+	j.retained = retainedInts(j)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.expire()
+	if len(q.pending) >= q.settings.QueueSize {
+		return JobResponse{}, ErrJobQueueFull
+	}
+	if q.settings.MaxRetained > 0 && j.retained > q.settings.MaxRetained-q.retained {
+		return JobResponse{}, fmt.Errorf("%w: %d integers held, %d more requested, at most %d allowed", ErrJobCapacity, q.retained, j.retained, q.settings.MaxRetained)
+	}
+	j.created = q.now()
+	q.pending = append(q.pending, j)
+	q.jobs[id] = j
+	q.retained += j.retained
+	q.signal()
+	return q.describe(j), nil
+}
+
+// retainedInts returns the number of integers the job holds once it finished:
+// those of every set when kept and those of the combined result when asked for.
+func retainedInts(j *job) int {
+	if !j.keepData {
+		return 0
+	}
+	var total int
+	for _, set := range j.params.setList() {
+		total = min(total, math.MaxInt-set.length) + set.length
+	}
+	if slices.Contains(j.params.fields, service.FieldData) {
+		total = min(total, math.MaxInt-total) + total
+	}
+	return total
+}
+
+func (q *jobQueue) get(id string) (JobResponse, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.expire()
+	j, ok := q.jobs[id]
+	if !ok {
+		return JobResponse{}, ErrJobNotFound
+	}
+	return q.describe(j), nil
+}
+
+// cancel stops a queued or running job. Finished jobs are left as they are.
+func (q *jobQueue) cancel(id string) (JobResponse, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.expire()
+	j, ok := q.jobs[id]
+	if !ok {
+		return JobResponse{}, ErrJobNotFound
+	}
+	switch j.status {
+	case JobQueued:
+		j.status = JobCancelled
+		j.finished = q.now()
+		// free its place in the queue right away
+		q.pending = slices.DeleteFunc(q.pending, func(pending *job) bool {
+			return pending == j
+		})
+	case JobRunning:
+		j.status = JobCancelled
+		j.cancel()
+	}
+	return q.describe(j), nil
+}
+
+func (q *jobQueue) execute(ctx context.Context, j *job) {
+	q.mu.Lock()
+	if j.status != JobQueued {
+		q.mu.Unlock()
+		return
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	j.status = JobRunning
+	j.cancel = cancel
+	q.mu.Unlock()
+
+	// results of a job are recorded under its id
+	ctx = context.WithValue(ctx, middleware.RequestIDKey, j.id)
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "server.Job",
+		trace.WithLinks(j.link),
+		trace.WithAttributes(attribute.String("job.id", j.id), attribute.Int("job.sets", j.progress.Total)),
+	)
+	defer span.End()
+
+	err := q.mean(ctx, j.params, func(res service.StdDevResult) error {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		if res.Combined {
+			j.combined = &res
+			return nil
+		}
+		if !j.keepData {
+			res.Data = nil
+		}
+		j.sets = append(j.sets, res)
+		j.progress.Completed++
+		return nil
+	})
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	j.finished = q.now()
+	switch {
+	case j.status == JobCancelled:
+		span.SetAttributes(attribute.Bool("job.cancelled", true))
+	case err != nil:
+		j.status = JobFailed
+		j.err = err
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		slog.Error("job failed", "timestamp", time.Now(), "job", j.id, "error", err)
+	default:
+		j.status = JobSucceeded
+	}
+}
+
+// expire removes jobs finished more than TTL ago. It must be called with mu held.
+func (q *jobQueue) expire() {
+	now := q.now()
+	for id, j := range q.jobs {
+		if !j.finished.IsZero() && now.Sub(j.finished) > q.settings.TTL {
+			delete(q.jobs, id)
+			q.retained -= j.retained
+		}
+	}
+}
+
+// describe must be called with mu held.
+func (q *jobQueue) describe(j *job) JobResponse {
+	res := JobResponse{
+		ID:        j.id,
+		Status:    j.status,
+		Progress:  j.progress,
+		CreatedAt: j.created,
+		Sets:      append([]service.StdDevResult{}, j.sets...),
+		Combined:  j.combined,
+	}
+	if !j.finished.IsZero() {
+		finished := j.finished
+		expires := finished.Add(q.settings.TTL)
+		res.FinishedAt = &finished
+		res.ExpiresAt = &expires
+	}
+	if j.err != nil {
+		_, code := classifyError(j.err)
+		res.Error = &ErrorResponse{Code: code, Message: j.err.Error()}
+	}
+	return res
+}
+
+func newJobID() (string, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate a job id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// CreateJob queues the sets listed in the JSON body, as accepted by
+// POST /random/mean, and answers with the job to poll.
+func (s *RandomServer) CreateJob(w http.ResponseWriter, r *http.Request) {
+	params, err := s.jobValidator.parseMeanSpec(w, r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	res, err := s.jobs.submit(r.Context(), params)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	slog.Info("job queued", "timestamp", time.Now(), "job", res.ID, "request_id", middleware.GetReqID(r.Context()), "sets", res.Progress.Total)
+	w.Header().Set("Location", "/jobs/"+res.ID)
+	writeJob(w, http.StatusAccepted, res)
+}
+
+func (s *RandomServer) GetJob(w http.ResponseWriter, r *http.Request) {
+	res, err := s.jobs.get(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJob(w, http.StatusOK, res)
+}
+
+func (s *RandomServer) CancelJob(w http.ResponseWriter, r *http.Request) {
+	res, err := s.jobs.cancel(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJob(w, http.StatusOK, res)
+}
+
+func writeJob(w http.ResponseWriter, status int, payload JobResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(payload)
+	if err != nil {
+		slog.Error("failed to encode the payload", "error", err)
+	}
+}
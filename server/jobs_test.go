@@ -0,0 +1,346 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/koenno/standard-deviation-service/server/mocks"
+	"github.com/koenno/standard-deviation-service/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func callJobs(t *testing.T, sut *RandomServer, method, URL, body string) (*httptest.ResponseRecorder, JobResponse) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	sut.srv.Handler.ServeHTTP(w, httptest.NewRequest(method, URL, strings.NewReader(body)))
+	var payload JobResponse
+	if w.Code < http.StatusBadRequest {
+		err := json.NewDecoder(w.Body).Decode(&payload)
+		assert.NoError(t, err)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	}
+	return w, payload
+}
+
+func waitForJob(t *testing.T, sut *RandomServer, id string, condition func(JobResponse) bool) JobResponse {
+	t.Helper()
+	var job JobResponse
+	assert.Eventually(t, func() bool {
+		_, job = callJobs(t, sut, http.MethodGet, "/jobs/"+id, "")
+		return condition(job)
+	}, time.Second, time.Millisecond)
+	return job
+}
+
+func blockUntilCancelled(called chan<- struct{}, upstreamErr chan<- error) func(ctx context.Context, quantity, min, max int) ([]int, error) {
+	return func(ctx context.Context, quantity, min, max int) ([]int, error) {
+		called <- struct{}{}
+		<-ctx.Done()
+		upstreamErr <- ctx.Err()
+		return nil, ctx.Err()
+	}
+}
+
+func TestShouldRunJobInBackground(t *testing.T) {
+	// given
+	port := 8080
+	generatorMock := mocks.NewRandomIntegerGenerator(t)
+	sut := NewRandomServer(generatorMock, service.NewStdDevService(), port)
+
+	generatorMock.EXPECT().Integers(mock.Anything, 2, defaultMin, defaultMax).Return([]int{1, 3}, nil).Twice()
+
+	// when
+	w, created := callJobs(t, sut, http.MethodPost, "/jobs", `{"sets": [{"length": 2}, {"length": 2}]}`)
+
+	// then
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, "/jobs/"+created.ID, w.Header().Get("Location"))
+	assert.Len(t, created.ID, 32)
+	assert.Equal(t, JobProgress{Completed: 0, Total: 2}, created.Progress)
+	job := waitForJob(t, sut, created.ID, func(job JobResponse) bool {
+		return job.Status == JobSucceeded
+	})
+	assert.Equal(t, JobProgress{Completed: 2, Total: 2}, job.Progress)
+	if assert.Len(t, job.Sets, 2) {
+		assert.Nil(t, job.Sets[0].Data)
+		assert.Nil(t, job.Sets[1].Data)
+	}
+	if assert.NotNil(t, job.Combined) {
+		assert.Equal(t, 1.0, job.Combined.StdDev)
+		assert.Nil(t, job.Combined.Data)
+	}
+	if assert.NotNil(t, job.FinishedAt) && assert.NotNil(t, job.ExpiresAt) {
+		assert.Equal(t, DefaultJobSettings().TTL, job.ExpiresAt.Sub(*job.FinishedAt))
+	}
+	assert.Nil(t, job.Error)
+}
+
+func TestShouldReportProgressOfRunningJob(t *testing.T) {
+	// given
+	port := 8080
+	generatorMock := mocks.NewRandomIntegerGenerator(t)
+	sut := NewRandomServer(generatorMock, service.NewStdDevService(), port)
+
+	release := make(chan struct{})
+	generatorMock.EXPECT().Integers(mock.Anything, 2, defaultMin, defaultMax).Return([]int{1, 3}, nil).Once()
+	generatorMock.EXPECT().Integers(mock.Anything, 3, defaultMin, defaultMax).RunAndReturn(func(ctx context.Context, quantity, min, max int) ([]int, error) {
+		<-release
+		return []int{2, 4, 6}, nil
+	}).Once()
+	_, created := callJobs(t, sut, http.MethodPost, "/jobs", `{"sets": [{"length": 2}, {"length": 3}]}`)
+
+	// when
+	running := waitForJob(t, sut, created.ID, func(job JobResponse) bool {
+		return job.Progress.Completed == 1
+	})
+	close(release)
+
+	// then
+	assert.Equal(t, JobRunning, running.Status)
+	assert.Equal(t, []service.StdDevResult{{StdDev: 1, Kind: service.Population}}, running.Sets)
+	assert.Nil(t, running.Combined)
+	assert.Nil(t, running.FinishedAt)
+	finished := waitForJob(t, sut, created.ID, func(job JobResponse) bool {
+		return job.Status == JobSucceeded
+	})
+	assert.Equal(t, JobProgress{Completed: 2, Total: 2}, finished.Progress)
+}
+
+func TestShouldReportFailedJob(t *testing.T) {
+	// given
+	port := 8080
+	generatorMock := mocks.NewRandomIntegerGenerator(t)
+	sut := NewRandomServer(generatorMock, service.NewStdDevService(), port)
+
+	generatorMock.EXPECT().Integers(mock.Anything, 2, defaultMin, defaultMax).Return(nil, context.DeadlineExceeded).Once()
+
+	// when
+	_, created := callJobs(t, sut, http.MethodPost, "/jobs", `{"sets": [{"length": 2}]}`)
+
+	// then
+	job := waitForJob(t, sut, created.ID, func(job JobResponse) bool {
+		return job.Status == JobFailed
+	})
+	if assert.NotNil(t, job.Error) {
+		assert.Equal(t, CodeUpstreamTimeout, job.Error.Code)
+	}
+	assert.NotNil(t, job.FinishedAt)
+}
+
+func TestShouldCancelRunningJob(t *testing.T) {
+	// given
+	port := 8080
+	generatorMock := mocks.NewRandomIntegerGenerator(t)
+	sut := NewRandomServer(generatorMock, service.NewStdDevService(), port)
+
+	called := make(chan struct{}, 1)
+	upstreamErr := make(chan error, 1)
+	generatorMock.EXPECT().Integers(mock.Anything, 2, defaultMin, defaultMax).RunAndReturn(blockUntilCancelled(called, upstreamErr)).Once()
+	_, created := callJobs(t, sut, http.MethodPost, "/jobs", `{"sets": [{"length": 2}]}`)
+	<-called
+
+	// when
+	w, cancelled := callJobs(t, sut, http.MethodDelete, "/jobs/"+created.ID, "")
+
+	// then
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, JobCancelled, cancelled.Status)
+	assert.ErrorIs(t, <-upstreamErr, context.Canceled)
+	job := waitForJob(t, sut, created.ID, func(job JobResponse) bool {
+		return job.FinishedAt != nil
+	})
+	assert.Equal(t, JobCancelled, job.Status)
+	assert.Nil(t, job.Error)
+}
+
+func TestShouldCancelQueuedJobWithoutRunningIt(t *testing.T) {
+	// given
+	port := 8080
+	generatorMock := mocks.NewRandomIntegerGenerator(t)
+	settings := DefaultJobSettings()
+	settings.Workers = 1
+	sut := NewRandomServer(generatorMock, service.NewStdDevService(), port, WithJobSettings(settings))
+
+	called := make(chan struct{}, 1)
+	upstreamErr := make(chan error, 1)
+	generatorMock.EXPECT().Integers(mock.Anything, 2, defaultMin, defaultMax).RunAndReturn(blockUntilCancelled(called, upstreamErr)).Once()
+	_, running := callJobs(t, sut, http.MethodPost, "/jobs", `{"sets": [{"length": 2}]}`)
+	<-called
+	_, queued := callJobs(t, sut, http.MethodPost, "/jobs", `{"sets": [{"length": 5}]}`)
+
+	// when
+	_, cancelled := callJobs(t, sut, http.MethodDelete, "/jobs/"+queued.ID, "")
+	callJobs(t, sut, http.MethodDelete, "/jobs/"+running.ID, "")
+
+	// then
+	assert.Equal(t, JobQueued, queued.Status)
+	assert.Equal(t, JobCancelled, cancelled.Status)
+	assert.NotNil(t, cancelled.FinishedAt)
+	assert.ErrorIs(t, <-upstreamErr, context.Canceled)
+	waitForJob(t, sut, running.ID, func(job JobResponse) bool {
+		return job.FinishedAt != nil
+	})
+	_, job := callJobs(t, sut, http.MethodGet, "/jobs/"+queued.ID, "")
+	assert.Equal(t, JobProgress{Completed: 0, Total: 1}, job.Progress)
+}
+
+func TestShouldRejectJobWhenQueueIsFull(t *testing.T) {
+	// given
+	port := 8080
+	settings := DefaultJobSettings()
+	settings.Workers = 0
+	settings.QueueSize = 1
+	sut := NewRandomServer(mocks.NewRandomIntegerGenerator(t), mocks.NewStdDevCalculator(t), port, WithJobSettings(settings))
+	callJobs(t, sut, http.MethodPost, "/jobs", `{"sets": [{"length": 2}]}`)
+
+	// when
+	w, _ := callJobs(t, sut, http.MethodPost, "/jobs", `{"sets": [{"length": 2}]}`)
+
+	// then
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	var payload ErrorResponse
+	err := json.NewDecoder(w.Body).Decode(&payload)
+	assert.NoError(t, err)
+	assert.Equal(t, CodeJobQueueFull, payload.Code)
+}
+
+func TestShouldKeepDataOfJobAskingForIt(t *testing.T) {
+	// given
+	port := 8080
+	generatorMock := mocks.NewRandomIntegerGenerator(t)
+	sut := NewRandomServer(generatorMock, service.NewStdDevService(), port)
+
+	generatorMock.EXPECT().Integers(mock.Anything, 2, defaultMin, defaultMax).Return([]int{1, 3}, nil).Once()
+	_, created := callJobs(t, sut, http.MethodPost, "/jobs", `{"fields": ["data"], "sets": [{"length": 2}]}`)
+
+	// when
+	job := waitForJob(t, sut, created.ID, func(job JobResponse) bool {
+		return job.Status == JobSucceeded
+	})
+
+	// then
+	if assert.Len(t, job.Sets, 1) {
+		assert.Equal(t, []int{1, 3}, job.Sets[0].Data)
+	}
+	if assert.NotNil(t, job.Combined) {
+		assert.Equal(t, []int{1, 3}, job.Combined.Data)
+	}
+}
+
+func TestShouldRejectJobWhenKeptJobsHoldTooManyIntegers(t *testing.T) {
+	// given
+	port := 8080
+	settings := DefaultJobSettings()
+	settings.Workers = 0
+	settings.MaxRetained = 10
+	sut := NewRandomServer(mocks.NewRandomIntegerGenerator(t), mocks.NewStdDevCalculator(t), port, WithJobSettings(settings))
+	first, _ := callJobs(t, sut, http.MethodPost, "/jobs", `{"fields": ["median"], "sets": [{"length": 6}]}`)
+	withoutData, _ := callJobs(t, sut, http.MethodPost, "/jobs", `{"sets": [{"length": 6}]}`)
+
+	// when
+	w, _ := callJobs(t, sut, http.MethodPost, "/jobs", `{"fields": ["data"], "sets": [{"length": 3}]}`)
+
+	// then
+	assert.Equal(t, http.StatusAccepted, first.Code)
+	assert.Equal(t, http.StatusAccepted, withoutData.Code)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	var payload ErrorResponse
+	err := json.NewDecoder(w.Body).Decode(&payload)
+	assert.NoError(t, err)
+	assert.Equal(t, CodeJobCapacity, payload.Code)
+}
+
+func TestShouldFreeQueueOfCancelledJob(t *testing.T) {
+	// given
+	port := 8080
+	settings := DefaultJobSettings()
+	settings.Workers = 0
+	settings.QueueSize = 1
+	sut := NewRandomServer(mocks.NewRandomIntegerGenerator(t), mocks.NewStdDevCalculator(t), port, WithJobSettings(settings))
+	_, queued := callJobs(t, sut, http.MethodPost, "/jobs", `{"sets": [{"length": 2}]}`)
+	callJobs(t, sut, http.MethodDelete, "/jobs/"+queued.ID, "")
+
+	// when
+	w, _ := callJobs(t, sut, http.MethodPost, "/jobs", `{"sets": [{"length": 2}]}`)
+
+	// then
+	assert.Equal(t, http.StatusAccepted, w.Code)
+}
+
+func TestShouldValidateJobsAgainstJobLimits(t *testing.T) {
+	// given
+	port := 8080
+	settings := DefaultJobSettings()
+	settings.Limits = Limits{MaxRequests: 1}
+	sut := NewRandomServer(mocks.NewRandomIntegerGenerator(t), mocks.NewStdDevCalculator(t), port, WithJobSettings(settings))
+
+	// when
+	w, _ := callJobs(t, sut, http.MethodPost, "/jobs", `{"sets": [{"length": 20000}, {"length": 1}]}`)
+
+	// then
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	var payload ErrorResponse
+	err := json.NewDecoder(w.Body).Decode(&payload)
+	assert.NoError(t, err)
+	assert.Equal(t, []FieldError{{Field: "sets", Message: "parameter must not exceed 1"}}, payload.Fields)
+}
+
+func TestShouldExpireFinishedJobs(t *testing.T) {
+	// given
+	port := 8080
+	generatorMock := mocks.NewRandomIntegerGenerator(t)
+	sut := NewRandomServer(generatorMock, service.NewStdDevService(), port)
+	var elapsed atomic.Int64
+	sut.jobs.now = func() time.Time {
+		return time.Now().Add(time.Duration(elapsed.Load()))
+	}
+
+	generatorMock.EXPECT().Integers(mock.Anything, 2, defaultMin, defaultMax).Return([]int{1, 3}, nil).Once()
+	_, created := callJobs(t, sut, http.MethodPost, "/jobs", `{"sets": [{"length": 2}]}`)
+	waitForJob(t, sut, created.ID, func(job JobResponse) bool {
+		return job.Status == JobSucceeded
+	})
+
+	// when
+	elapsed.Store(int64(DefaultJobSettings().TTL + time.Second))
+	w, _ := callJobs(t, sut, http.MethodGet, "/jobs/"+created.ID, "")
+
+	// then
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	var payload ErrorResponse
+	err := json.NewDecoder(w.Body).Decode(&payload)
+	assert.NoError(t, err)
+	assert.Equal(t, CodeJobNotFound, payload.Code)
+}
+
+func TestShouldExpireFinishedJobsOfIdleQueue(t *testing.T) {
+	// given
+	port := 8080
+	generatorMock := mocks.NewRandomIntegerGenerator(t)
+	settings := DefaultJobSettings()
+	settings.TTL = 200 * time.Millisecond
+	sut := NewRandomServer(generatorMock, service.NewStdDevService(), port, WithJobSettings(settings))
+
+	generatorMock.EXPECT().Integers(mock.Anything, 2, defaultMin, defaultMax).Return([]int{1, 3}, nil).Once()
+	_, created := callJobs(t, sut, http.MethodPost, "/jobs", `{"sets": [{"length": 2}]}`)
+	waitForJob(t, sut, created.ID, func(job JobResponse) bool {
+		return job.Status == JobSucceeded
+	})
+
+	// when
+	expired := func() bool {
+		sut.jobs.mu.Lock()
+		defer sut.jobs.mu.Unlock()
+		return len(sut.jobs.jobs) == 0
+	}
+
+	// then
+	assert.Eventually(t, expired, 2*time.Second, time.Millisecond)
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/koenno/standard-deviation-service/metrics"
+	"github.com/koenno/standard-deviation-service/server/mocks"
+	"github.com/koenno/standard-deviation-service/service"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// scrape parses the exposition served on /metrics.
+func scrape(t *testing.T, handler http.Handler) map[string]*dto.MetricFamily {
+	t.Helper()
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(w.Body)
+	assert.NoError(t, err)
+	return families
+}
+
+// sample returns the metric of the family with exactly the given labels.
+func sample(families map[string]*dto.MetricFamily, name string, labels map[string]string) *dto.Metric {
+	family, ok := families[name]
+	if !ok {
+		return nil
+	}
+	for _, m := range family.GetMetric() {
+		if len(m.GetLabel()) != len(labels) {
+			continue
+		}
+		matches := true
+		for _, l := range m.GetLabel() {
+			if labels[l.GetName()] != l.GetValue() {
+				matches = false
+			}
+		}
+		if matches {
+			return m
+		}
+	}
+	return nil
+}
+
+func TestShouldExposeMetricsOfHandledRequests(t *testing.T) {
+	// given
+	port := 8080
+	generatorMock := mocks.NewRandomIntegerGenerator(t)
+	calculatorMock := mocks.NewStdDevCalculator(t)
+	sut := NewRandomServer(generatorMock, calculatorMock, port, WithMetrics(metrics.New()))
+
+	generatorMock.EXPECT().Integers(mock.Anything, 5, defaultMin, defaultMax).Return([]int{1, 2, 3, 4, 5}, nil).Twice()
+	calcPipe := make(chan service.StdDevResult)
+	close(calcPipe)
+	calculatorMock.EXPECT().Calculate(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(calcPipe).Once()
+
+	// when
+	sut.srv.Handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/random/mean?requests=2&length=5", nil))
+	sut.srv.Handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/random/mean?requests=0&length=5", nil))
+	sut.srv.Handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/no/such/route", nil))
+	families := scrape(t, sut.srv.Handler)
+
+	// then
+	ok := sample(families, "stddev_http_requests_total", map[string]string{"route": "/random/mean", "method": "GET", "status": "200"})
+	if assert.NotNil(t, ok) {
+		assert.Equal(t, 1.0, ok.GetCounter().GetValue())
+	}
+	invalid := sample(families, "stddev_http_requests_total", map[string]string{"route": "/random/mean", "method": "GET", "status": "400"})
+	if assert.NotNil(t, invalid) {
+		assert.Equal(t, 1.0, invalid.GetCounter().GetValue())
+	}
+	notFound := sample(families, "stddev_http_requests_total", map[string]string{"route": unmatchedRoute, "method": "GET", "status": "404"})
+	if assert.NotNil(t, notFound) {
+		assert.Equal(t, 1.0, notFound.GetCounter().GetValue())
+	}
+	latency := sample(families, "stddev_http_request_duration_seconds", map[string]string{"route": "/random/mean", "method": "GET", "status": "200"})
+	if assert.NotNil(t, latency) {
+		assert.Equal(t, uint64(1), latency.GetHistogram().GetSampleCount())
+	}
+	generated := sample(families, "stddev_generated_integers_total", map[string]string{"source": defaultSource})
+	if assert.NotNil(t, generated) {
+		assert.Equal(t, 10.0, generated.GetCounter().GetValue())
+	}
+	inFlight := sample(families, "stddev_mean_pipelines_in_flight", map[string]string{})
+	if assert.NotNil(t, inFlight) {
+		assert.Equal(t, 0.0, inFlight.GetGauge().GetValue())
+	}
+}
@@ -21,25 +21,25 @@ func (_m *RandomIntegerGenerator) EXPECT() *RandomIntegerGenerator_Expecter {
 	return &RandomIntegerGenerator_Expecter{mock: &_m.Mock}
 }
 
-// Integers provides a mock function with given fields: ctx, quantity
-func (_m *RandomIntegerGenerator) Integers(ctx context.Context, quantity int) ([]int, error) {
-	ret := _m.Called(ctx, quantity)
+// Integers provides a mock function with given fields: ctx, quantity, min, max
+func (_m *RandomIntegerGenerator) Integers(ctx context.Context, quantity int, min int, max int) ([]int, error) {
+	ret := _m.Called(ctx, quantity, min, max)
 
 	var r0 []int
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int) ([]int, error)); ok {
-		return rf(ctx, quantity)
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int) ([]int, error)); ok {
+		return rf(ctx, quantity, min, max)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int) []int); ok {
-		r0 = rf(ctx, quantity)
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int) []int); ok {
+		r0 = rf(ctx, quantity, min, max)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]int)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
-		r1 = rf(ctx, quantity)
+	if rf, ok := ret.Get(1).(func(context.Context, int, int, int) error); ok {
+		r1 = rf(ctx, quantity, min, max)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -55,13 +55,15 @@ type RandomIntegerGenerator_Integers_Call struct {
 // Integers is a helper method to define mock.On call
 //   - ctx context.Context
 //   - quantity int
-func (_e *RandomIntegerGenerator_Expecter) Integers(ctx interface{}, quantity interface{}) *RandomIntegerGenerator_Integers_Call {
-	return &RandomIntegerGenerator_Integers_Call{Call: _e.mock.On("Integers", ctx, quantity)}
+//   - min int
+//   - max int
+func (_e *RandomIntegerGenerator_Expecter) Integers(ctx interface{}, quantity interface{}, min interface{}, max interface{}) *RandomIntegerGenerator_Integers_Call {
+	return &RandomIntegerGenerator_Integers_Call{Call: _e.mock.On("Integers", ctx, quantity, min, max)}
 }
 
-func (_c *RandomIntegerGenerator_Integers_Call) Run(run func(ctx context.Context, quantity int)) *RandomIntegerGenerator_Integers_Call {
+func (_c *RandomIntegerGenerator_Integers_Call) Run(run func(ctx context.Context, quantity int, min int, max int)) *RandomIntegerGenerator_Integers_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(int))
+		run(args[0].(context.Context), args[1].(int), args[2].(int), args[3].(int))
 	})
 	return _c
 }
@@ -71,7 +73,7 @@ func (_c *RandomIntegerGenerator_Integers_Call) Return(_a0 []int, _a1 error) *Ra
 	return _c
 }
 
-func (_c *RandomIntegerGenerator_Integers_Call) RunAndReturn(run func(context.Context, int) ([]int, error)) *RandomIntegerGenerator_Integers_Call {
+func (_c *RandomIntegerGenerator_Integers_Call) RunAndReturn(run func(context.Context, int, int, int) ([]int, error)) *RandomIntegerGenerator_Integers_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -3,6 +3,8 @@
 package mocks
 
 import (
+	context "context"
+
 	mock "github.com/stretchr/testify/mock"
 
 	service "github.com/koenno/standard-deviation-service/service"
@@ -21,13 +23,13 @@ func (_m *StdDevCalculator) EXPECT() *StdDevCalculator_Expecter {
 	return &StdDevCalculator_Expecter{mock: &_m.Mock}
 }
 
-// Calculate provides a mock function with given fields: input
-func (_m *StdDevCalculator) Calculate(input <-chan []int) <-chan service.StdDevResult {
-	ret := _m.Called(input)
+// Calculate provides a mock function with given fields: ctx, input, kind, fields
+func (_m *StdDevCalculator) Calculate(ctx context.Context, input <-chan []int, kind service.Kind, fields []service.Field) <-chan service.StdDevResult {
+	ret := _m.Called(ctx, input, kind, fields)
 
 	var r0 <-chan service.StdDevResult
-	if rf, ok := ret.Get(0).(func(<-chan []int) <-chan service.StdDevResult); ok {
-		r0 = rf(input)
+	if rf, ok := ret.Get(0).(func(context.Context, <-chan []int, service.Kind, []service.Field) <-chan service.StdDevResult); ok {
+		r0 = rf(ctx, input, kind, fields)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(<-chan service.StdDevResult)
@@ -43,14 +45,17 @@ type StdDevCalculator_Calculate_Call struct {
 }
 
 // Calculate is a helper method to define mock.On call
+//   - ctx context.Context
 //   - input <-chan []int
-func (_e *StdDevCalculator_Expecter) Calculate(input interface{}) *StdDevCalculator_Calculate_Call {
-	return &StdDevCalculator_Calculate_Call{Call: _e.mock.On("Calculate", input)}
+//   - kind service.Kind
+//   - fields []service.Field
+func (_e *StdDevCalculator_Expecter) Calculate(ctx interface{}, input interface{}, kind interface{}, fields interface{}) *StdDevCalculator_Calculate_Call {
+	return &StdDevCalculator_Calculate_Call{Call: _e.mock.On("Calculate", ctx, input, kind, fields)}
 }
 
-func (_c *StdDevCalculator_Calculate_Call) Run(run func(input <-chan []int)) *StdDevCalculator_Calculate_Call {
+func (_c *StdDevCalculator_Calculate_Call) Run(run func(ctx context.Context, input <-chan []int, kind service.Kind, fields []service.Field)) *StdDevCalculator_Calculate_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(<-chan []int))
+		run(args[0].(context.Context), args[1].(<-chan []int), args[2].(service.Kind), args[3].([]service.Field))
 	})
 	return _c
 }
@@ -60,7 +65,7 @@ func (_c *StdDevCalculator_Calculate_Call) Return(_a0 <-chan service.StdDevResul
 	return _c
 }
 
-func (_c *StdDevCalculator_Calculate_Call) RunAndReturn(run func(<-chan []int) <-chan service.StdDevResult) *StdDevCalculator_Calculate_Call {
+func (_c *StdDevCalculator_Calculate_Call) RunAndReturn(run func(context.Context, <-chan []int, service.Kind, []service.Field) <-chan service.StdDevResult) *StdDevCalculator_Calculate_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"time"
+
+	"github.com/koenno/standard-deviation-service/metrics"
+)
+
+const defaultTimeout = 60 * time.Second
+
+type Option func(*RandomServer)
+
+// Limits caps the size of a single calculation. A zero value disables
+// the corresponding limit.
+type Limits struct {
+	MaxRequests int
+	MaxLength   int
+	MaxTotal    int
+	// MaxConcurrency is the number of sets of a calculation generated at once.
+	MaxConcurrency int
+}
+
+func DefaultLimits() Limits {
+	return Limits{
+		MaxRequests: 100,
+		MaxLength:   10_000,
+		MaxTotal:    100_000,
+	}
+}
+
+func WithLimits(limits Limits) Option {
+	return func(s *RandomServer) {
+		s.limits = limits
+	}
+}
+
+// WithGenerator registers an additional generator selectable with the source query parameter.
+func WithGenerator(source string, generator RandomIntegerGenerator) Option {
+	return func(s *RandomServer) {
+		s.generators[source] = generator
+	}
+}
+
+// WithDefaultSource names the generator passed to NewRandomServer.
+func WithDefaultSource(source string) Option {
+	return func(s *RandomServer) {
+		s.defaultSource = source
+	}
+}
+
+// WithStatusReporter exposes the status of a component under the given name on /status.
+func WithStatusReporter(name string, reporter StatusReporter) Option {
+	return func(s *RandomServer) {
+		s.reporters[name] = reporter
+	}
+}
+
+// WithMetrics records the server metrics in m and serves them on /metrics.
+func WithMetrics(m *metrics.Metrics) Option {
+	return func(s *RandomServer) {
+		s.metrics = m
+	}
+}
+
+// WithReadinessCheck makes /readyz fail while the checker reports an error.
+func WithReadinessCheck(name string, checker ReadinessChecker) Option {
+	return func(s *RandomServer) {
+		s.checks[name] = checker
+	}
+}
+
+// WithDefaultRange sets the range used when a request omits min and max.
+func WithDefaultRange(min, max int) Option {
+	return func(s *RandomServer) {
+		s.defaultMin = min
+		s.defaultMax = max
+	}
+}
+
+// WithTimeout limits the time a single request may take.
+func WithTimeout(timeout time.Duration) Option {
+	return func(s *RandomServer) {
+		s.timeout = timeout
+	}
+}
+
+// WithShutdownDelay keeps Stop serving requests for delay after the server
+// became unready and before it stops accepting connections.
+func WithShutdownDelay(delay time.Duration) Option {
+	return func(s *RandomServer) {
+		s.shutdownDelay = delay
+	}
+}
+
+// WithJobSettings configures the workers, queue, expiry and limits of /jobs.
+func WithJobSettings(settings JobSettings) Option {
+	return func(s *RandomServer) {
+		s.jobSettings = settings
+	}
+}
+
+// WithResultStore records the results of every calculation in rs and serves
+// them on /results.
+func WithResultStore(rs ResultStore) Option {
+	return func(s *RandomServer) {
+		s.results = rs
+	}
+}
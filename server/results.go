@@ -0,0 +1,177 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/koenno/standard-deviation-service/service"
+	"github.com/koenno/standard-deviation-service/store"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/exp/slog"
+)
+
+const maxResultsLimit = 1000
+
+var (
+	ErrParamNotTime   = errors.New("parameter must be an RFC 3339 timestamp")
+	ErrParamNotCursor = errors.New("parameter must be a cursor returned by a previous page")
+)
+
+// ResultsResponse is a page of GET /results. NextCursor is omitted on the last page.
+type ResultsResponse struct {
+	Results    []store.Record `json:"results"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+// uniqueRequestID rejects calculations whose client chose the request id of
+// one already recorded, so that a record cannot be hidden by reusing its id.
+func (s *RandomServer) uniqueRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(middleware.RequestIDHeader) != "" {
+			requestID := middleware.GetReqID(r.Context())
+			_, err := s.results.Get(requestID)
+			switch {
+			case err == nil:
+				writeError(w, r, fmt.Errorf("%w: %s", store.ErrExists, requestID))
+				return
+			case !errors.Is(err, store.ErrNotFound):
+				writeError(w, r, err)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// record saves the results of a calculation under the request id. A failure
+// is logged but does not fail the request.
+func (s *RandomServer) record(ctx context.Context, params meanParams, results []service.StdDevResult) {
+	record := store.Record{
+		RequestID: middleware.GetReqID(ctx),
+		Timestamp: time.Now().UTC(),
+		Params: store.Params{
+			Kind:   params.kind,
+			Fields: params.fields,
+		},
+		Sets: make([]service.StdDevResult, 0, len(results)),
+	}
+	for _, set := range params.setList() {
+		record.Params.Sets = append(record.Params.Sets, store.SetParams{
+			Length: set.length,
+			Min:    set.min,
+			Max:    set.max,
+			Source: set.source,
+		})
+	}
+	for _, singleRes := range results {
+		if singleRes.Combined {
+			combined := singleRes
+			record.Combined = &combined
+			continue
+		}
+		record.Sets = append(record.Sets, singleRes)
+	}
+
+	err := s.results.Save(record)
+	if err != nil {
+		trace.SpanFromContext(ctx).RecordError(err)
+		slog.Error("failed to record the results", "timestamp", time.Now(), "request_id", record.RequestID, "error", err)
+	}
+}
+
+// GetResult returns the recorded results of a request.
+func (s *RandomServer) GetResult(w http.ResponseWriter, r *http.Request) {
+	requestID, err := url.PathUnescape(chi.URLParam(r, "*"))
+	if err != nil {
+		writeError(w, r, &ParamError{Param: "requestID", Err: err})
+		return
+	}
+	record, err := s.results.Get(requestID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, record)
+}
+
+// ListResults returns the recorded results within the from and to query
+// parameters, oldest first, a page of at most limit records at a time.
+func (s *RandomServer) ListResults(w http.ResponseWriter, r *http.Request) {
+	query, err := parseResultsQuery(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	page, err := s.results.List(query)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	payload := ResultsResponse{Results: page.Records}
+	if payload.Results == nil {
+		payload.Results = []store.Record{}
+	}
+	if page.NextCursor != 0 {
+		payload.NextCursor = strconv.FormatUint(page.NextCursor, 10)
+	}
+	writeJSON(w, payload)
+}
+
+func parseResultsQuery(r *http.Request) (store.Query, error) {
+	var query store.Query
+	var err error
+	query.From, err = paramTime(r, "from")
+	if err != nil {
+		return store.Query{}, err
+	}
+	query.To, err = paramTime(r, "to")
+	if err != nil {
+		return store.Query{}, err
+	}
+	query.Limit = store.DefaultLimit
+	if r.URL.Query().Get("limit") != "" {
+		query.Limit, err = paramPositiveInt(r, "limit")
+		if err != nil {
+			return store.Query{}, err
+		}
+		if query.Limit > maxResultsLimit {
+			return store.Query{}, &ParamError{Param: "limit", Err: fmt.Errorf("%w %d", ErrParamTooLarge, maxResultsLimit)}
+		}
+	}
+	if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+		query.Cursor, err = strconv.ParseUint(cursor, 10, 64)
+		if err != nil {
+			return store.Query{}, &ParamError{Param: "cursor", Err: ErrParamNotCursor}
+		}
+	}
+	return query, nil
+}
+
+func paramTime(r *http.Request, param string) (time.Time, error) {
+	valueStr := r.URL.Query().Get(param)
+	if valueStr == "" {
+		return time.Time{}, nil
+	}
+	value, err := time.Parse(time.RFC3339, valueStr)
+	if err != nil {
+		return time.Time{}, &ParamError{Param: param, Err: ErrParamNotTime}
+	}
+	return value, nil
+}
+
+func writeJSON(w http.ResponseWriter, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(payload)
+	if err != nil {
+		slog.Error("failed to encode the payload", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,302 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/koenno/standard-deviation-service/server/mocks"
+	"github.com/koenno/standard-deviation-service/service"
+	"github.com/koenno/standard-deviation-service/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func getResults(sut *RandomServer, URL string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	sut.srv.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, URL, nil))
+	return w
+}
+
+func TestShouldRecordResultsOfRequest(t *testing.T) {
+	// given
+	port := 8080
+	generatorMock := mocks.NewRandomIntegerGenerator(t)
+	storeMock := mocks.NewResultStore(t)
+	sut := NewRandomServer(generatorMock, service.NewStdDevService(), port, WithResultStore(storeMock))
+
+	generatorMock.EXPECT().Integers(mock.Anything, 2, 0, 5).Return([]int{1, 3}, nil).Twice()
+	var saved store.Record
+	storeMock.EXPECT().Save(mock.Anything).Run(func(record store.Record) {
+		saved = record
+	}).Return(nil).Once()
+	before := time.Now()
+
+	// when
+	w := getResults(sut, "/random/mean?requests=2&length=2&min=0&max=5&fields=sum")
+
+	// then
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEmpty(t, saved.RequestID)
+	assert.Equal(t, saved.RequestID, w.Header().Get("X-Request-Id"))
+	assert.WithinRange(t, saved.Timestamp, before, time.Now())
+	expectedParams := store.Params{
+		Kind:   service.Population,
+		Fields: []service.Field{service.FieldSum},
+		Sets: []store.SetParams{
+			{Length: 2, Min: 0, Max: 5, Source: defaultSource},
+			{Length: 2, Min: 0, Max: 5, Source: defaultSource},
+		},
+	}
+	assert.Equal(t, expectedParams, saved.Params)
+	assert.Len(t, saved.Sets, 2)
+	if assert.NotNil(t, saved.Combined) {
+		assert.Equal(t, []int{1, 3, 1, 3}, saved.Combined.Data)
+	}
+}
+
+func TestShouldRecordResultsUnderRequestIDOfClient(t *testing.T) {
+	// given
+	port := 8080
+	generatorMock := mocks.NewRandomIntegerGenerator(t)
+	storeMock := mocks.NewResultStore(t)
+	sut := NewRandomServer(generatorMock, service.NewStdDevService(), port, WithResultStore(storeMock))
+	req := httptest.NewRequest(http.MethodGet, "/random/mean?requests=1&length=2", nil)
+	req.Header.Set("X-Request-Id", "draw-42")
+	w := httptest.NewRecorder()
+
+	storeMock.EXPECT().Get("draw-42").Return(store.Record{}, store.ErrNotFound).Once()
+	generatorMock.EXPECT().Integers(mock.Anything, 2, defaultMin, defaultMax).Return([]int{1, 3}, nil).Once()
+	storeMock.EXPECT().Save(mock.MatchedBy(func(record store.Record) bool {
+		return record.RequestID == "draw-42"
+	})).Return(nil).Once()
+
+	// when
+	sut.srv.Handler.ServeHTTP(w, req)
+
+	// then
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "draw-42", w.Header().Get("X-Request-Id"))
+}
+
+func TestShouldRejectRequestIDOfRecordedResult(t *testing.T) {
+	// given
+	port := 8080
+	generatorMock := mocks.NewRandomIntegerGenerator(t)
+	storeMock := mocks.NewResultStore(t)
+	sut := NewRandomServer(generatorMock, service.NewStdDevService(), port, WithResultStore(storeMock))
+	req := httptest.NewRequest(http.MethodGet, "/random/mean?requests=1&length=2", nil)
+	req.Header.Set("X-Request-Id", "draw-42")
+	w := httptest.NewRecorder()
+
+	storeMock.EXPECT().Get("draw-42").Return(store.Record{RequestID: "draw-42"}, nil).Once()
+
+	// when
+	sut.srv.Handler.ServeHTTP(w, req)
+
+	// then
+	assert.Equal(t, http.StatusConflict, w.Code)
+	var payload ErrorResponse
+	err := json.NewDecoder(w.Body).Decode(&payload)
+	assert.NoError(t, err)
+	assert.Equal(t, CodeRequestIDConflict, payload.Code)
+	assert.Equal(t, "draw-42", payload.RequestID)
+}
+
+func TestShouldAnswerWhenRecordingFails(t *testing.T) {
+	// given
+	port := 8080
+	generatorMock := mocks.NewRandomIntegerGenerator(t)
+	storeMock := mocks.NewResultStore(t)
+	sut := NewRandomServer(generatorMock, service.NewStdDevService(), port, WithResultStore(storeMock))
+
+	generatorMock.EXPECT().Integers(mock.Anything, 2, defaultMin, defaultMax).Return([]int{1, 3}, nil).Once()
+	storeMock.EXPECT().Save(mock.Anything).Return(errors.New("disk full")).Once()
+
+	// when
+	w := getResults(sut, "/random/mean?requests=1&length=2")
+
+	// then
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestShouldNotRecordFailedCalculation(t *testing.T) {
+	// given
+	port := 8080
+	generatorMock := mocks.NewRandomIntegerGenerator(t)
+	storeMock := mocks.NewResultStore(t)
+	sut := NewRandomServer(generatorMock, service.NewStdDevService(), port, WithResultStore(storeMock))
+
+	generatorMock.EXPECT().Integers(mock.Anything, 2, defaultMin, defaultMax).Return(nil, errors.New("failure")).Once()
+
+	// when
+	w := getResults(sut, "/random/mean?requests=1&length=2")
+
+	// then
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestShouldRecordJobUnderItsID(t *testing.T) {
+	// given
+	port := 8080
+	generatorMock := mocks.NewRandomIntegerGenerator(t)
+	storeMock := mocks.NewResultStore(t)
+	sut := NewRandomServer(generatorMock, service.NewStdDevService(), port, WithResultStore(storeMock))
+
+	generatorMock.EXPECT().Integers(mock.Anything, 2, defaultMin, defaultMax).Return([]int{1, 3}, nil).Once()
+	saved := make(chan store.Record, 1)
+	storeMock.EXPECT().Save(mock.Anything).Run(func(record store.Record) {
+		saved <- record
+	}).Return(nil).Once()
+
+	// when
+	_, created := callJobs(t, sut, http.MethodPost, "/jobs", `{"sets": [{"length": 2}]}`)
+
+	// then
+	assert.Equal(t, created.ID, (<-saved).RequestID)
+}
+
+func TestShouldReturnRecordedResult(t *testing.T) {
+	tests := []struct {
+		name string
+		URL  string
+	}{
+		{name: "plain request id", URL: "/results/host/abcdef-000001"},
+		{name: "escaped request id", URL: "/results/host%2Fabcdef-000001"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// given
+			port := 8080
+			storeMock := mocks.NewResultStore(t)
+			sut := NewRandomServer(mocks.NewRandomIntegerGenerator(t), mocks.NewStdDevCalculator(t), port, WithResultStore(storeMock))
+			expected := store.Record{
+				RequestID: "host/abcdef-000001",
+				Timestamp: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+				Params:    store.Params{Kind: service.Population, Sets: []store.SetParams{{Length: 2, Min: 1, Max: 10, Source: "local"}}},
+				Sets:      []service.StdDevResult{{StdDev: 1, Kind: service.Population, Data: []int{1, 3}}},
+			}
+			storeMock.EXPECT().Get("host/abcdef-000001").Return(expected, nil).Once()
+
+			// when
+			w := getResults(sut, test.URL)
+
+			// then
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			var actual store.Record
+			err := json.NewDecoder(w.Body).Decode(&actual)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
+
+func TestShouldReturnNotFoundForUnknownResult(t *testing.T) {
+	// given
+	port := 8080
+	storeMock := mocks.NewResultStore(t)
+	sut := NewRandomServer(mocks.NewRandomIntegerGenerator(t), mocks.NewStdDevCalculator(t), port, WithResultStore(storeMock))
+	storeMock.EXPECT().Get("unknown").Return(store.Record{}, store.ErrNotFound).Once()
+
+	// when
+	w := getResults(sut, "/results/unknown")
+
+	// then
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	var payload ErrorResponse
+	err := json.NewDecoder(w.Body).Decode(&payload)
+	assert.NoError(t, err)
+	assert.Equal(t, CodeResultNotFound, payload.Code)
+}
+
+func TestShouldListRecordedResults(t *testing.T) {
+	// given
+	port := 8080
+	storeMock := mocks.NewResultStore(t)
+	sut := NewRandomServer(mocks.NewRandomIntegerGenerator(t), mocks.NewStdDevCalculator(t), port, WithResultStore(storeMock))
+	query := store.Query{
+		From:   time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		To:     time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC),
+		Cursor: 4,
+		Limit:  2,
+	}
+	records := []store.Record{{RequestID: "a"}, {RequestID: "b"}}
+	storeMock.EXPECT().List(query).Return(store.Page{Records: records, NextCursor: 6}, nil).Once()
+
+	// when
+	w := getResults(sut, "/results?from=2024-05-01T00:00:00Z&to=2024-05-02T00:00:00Z&limit=2&cursor=4")
+
+	// then
+	assert.Equal(t, http.StatusOK, w.Code)
+	var payload ResultsResponse
+	err := json.NewDecoder(w.Body).Decode(&payload)
+	assert.NoError(t, err)
+	assert.Equal(t, "6", payload.NextCursor)
+	if assert.Len(t, payload.Results, 2) {
+		assert.Equal(t, "a", payload.Results[0].RequestID)
+		assert.Equal(t, "b", payload.Results[1].RequestID)
+	}
+}
+
+func TestShouldListWithDefaultLimitAndOmitCursorOfLastPage(t *testing.T) {
+	// given
+	port := 8080
+	storeMock := mocks.NewResultStore(t)
+	sut := NewRandomServer(mocks.NewRandomIntegerGenerator(t), mocks.NewStdDevCalculator(t), port, WithResultStore(storeMock))
+	storeMock.EXPECT().List(store.Query{Limit: store.DefaultLimit}).Return(store.Page{}, nil).Once()
+
+	// when
+	w := getResults(sut, "/results")
+
+	// then
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"results": []}`, w.Body.String())
+}
+
+func TestShouldRejectInvalidListParameters(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedParam  string
+	}{
+		{name: "malformed from", query: "from=yesterday", expectedStatus: http.StatusBadRequest, expectedParam: "from"},
+		{name: "date without time", query: "to=2024-05-01", expectedStatus: http.StatusBadRequest, expectedParam: "to"},
+		{name: "zero limit", query: "limit=0", expectedStatus: http.StatusBadRequest, expectedParam: "limit"},
+		{name: "limit too large", query: "limit=1001", expectedStatus: http.StatusUnprocessableEntity, expectedParam: "limit"},
+		{name: "malformed cursor", query: "cursor=-1", expectedStatus: http.StatusBadRequest, expectedParam: "cursor"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// given
+			port := 8080
+			sut := NewRandomServer(mocks.NewRandomIntegerGenerator(t), mocks.NewStdDevCalculator(t), port, WithResultStore(mocks.NewResultStore(t)))
+
+			// when
+			w := getResults(sut, "/results?"+test.query)
+
+			// then
+			assert.Equal(t, test.expectedStatus, w.Code)
+			var payload ErrorResponse
+			err := json.NewDecoder(w.Body).Decode(&payload)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedParam, payload.Parameter)
+		})
+	}
+}
+
+func TestShouldNotServeResultsWithoutStore(t *testing.T) {
+	// given
+	port := 8080
+	sut := NewRandomServer(mocks.NewRandomIntegerGenerator(t), mocks.NewStdDevCalculator(t), port)
+
+	// when
+	w := getResults(sut, "/results")
+
+	// then
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -3,82 +3,304 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/koenno/standard-deviation-service/metrics"
 	"github.com/koenno/standard-deviation-service/service"
+	"github.com/koenno/standard-deviation-service/store"
 	"golang.org/x/exp/slog"
 	"golang.org/x/sync/errgroup"
 )
 
 //go:generate mockery --name=RandomIntegerGenerator --case underscore --with-expecter
 type RandomIntegerGenerator interface {
-	Integers(ctx context.Context, quantity int) ([]int, error)
+	Integers(ctx context.Context, quantity, min, max int) ([]int, error)
 }
 
 //go:generate mockery --name=StdDevCalculator --case underscore --with-expecter
 type StdDevCalculator interface {
-	Calculate(input <-chan []int) <-chan service.StdDevResult
+	Calculate(ctx context.Context, input <-chan []int, kind service.Kind, fields []service.Field) <-chan service.StdDevResult
+}
+
+//go:generate mockery --name=StatusReporter --case underscore --with-expecter
+type StatusReporter interface {
+	Status() any
+}
+
+//go:generate mockery --name=ReadinessChecker --case underscore --with-expecter
+type ReadinessChecker interface {
+	Ready() error
+}
+
+// ReadinessCheckerFunc adapts a function to a ReadinessChecker.
+type ReadinessCheckerFunc func() error
+
+func (f ReadinessCheckerFunc) Ready() error {
+	return f()
+}
+
+// ErrDrainTimeout is returned by Stop when in-flight requests did not finish
+// before the deadline and had to be cancelled.
+var ErrDrainTimeout = errors.New("in-flight requests did not finish in time")
+
+// ErrShuttingDown rejects requests arriving once Stop stopped waiting for
+// in-flight ones.
+var ErrShuttingDown = errors.New("server is shutting down")
+
+//go:generate mockery --name=ResultStore --case underscore --with-expecter
+type ResultStore interface {
+	Save(record store.Record) error
+	Get(requestID string) (store.Record, error)
+	List(query store.Query) (store.Page, error)
 }
 
 type RandomServer struct {
-	srv        http.Server
-	generator  RandomIntegerGenerator
-	calculator StdDevCalculator
-	port       int
+	srv           http.Server
+	generators    map[string]RandomIntegerGenerator
+	defaultSource string
+	calculator    StdDevCalculator
+	port          int
+	limits        Limits
+	validator     validator
+	reporters     map[string]StatusReporter
+	metrics       *metrics.Metrics
+	checks        map[string]ReadinessChecker
+	draining      atomic.Bool
+	defaultMin    int
+	defaultMax    int
+	timeout       time.Duration
+	jobSettings   JobSettings
+	jobValidator  validator
+	jobs          *jobQueue
+	results       ResultStore
+	shutdownDelay time.Duration
+
+	// baseCtx is the parent of every request context, cancelled by Stop
+	// once the drain deadline passes.
+	baseCtx        context.Context
+	cancelInFlight context.CancelFunc
+	inFlight       sync.WaitGroup
+	stopped        chan struct{}
+	stopOnce       sync.Once
+
+	// closed, guarded by closeMu, stops trackInFlight from adding requests
+	// to inFlight while Stop waits for it.
+	closeMu sync.Mutex
+	closed  bool
 }
 
-func NewRandomServer(generator RandomIntegerGenerator, calculator StdDevCalculator, port int) *RandomServer {
+func NewRandomServer(generator RandomIntegerGenerator, calculator StdDevCalculator, port int, opts ...Option) *RandomServer {
+	s := &RandomServer{
+		generators:    make(map[string]RandomIntegerGenerator),
+		defaultSource: defaultSource,
+		calculator:    calculator,
+		port:          port,
+		limits:        DefaultLimits(),
+		reporters:     make(map[string]StatusReporter),
+		metrics:       metrics.New(),
+		checks:        make(map[string]ReadinessChecker),
+		defaultMin:    defaultMin,
+		defaultMax:    defaultMax,
+		timeout:       defaultTimeout,
+		jobSettings:   DefaultJobSettings(),
+		stopped:       make(chan struct{}),
+	}
+	s.baseCtx, s.cancelInFlight = context.WithCancel(context.Background())
+	for _, o := range opts {
+		o(s)
+	}
+	s.generators[s.defaultSource] = generator
+
+	sources := make([]string, 0, len(s.generators))
+	for source := range s.generators {
+		sources = append(sources, source)
+	}
+	slices.Sort(sources)
+	s.validator = validator{
+		limits:        s.limits,
+		sources:       sources,
+		defaultSource: s.defaultSource,
+		defaultMin:    s.defaultMin,
+		defaultMax:    s.defaultMax,
+	}
+	validation := validationMiddleware(s.validator)
+	s.jobValidator = s.validator
+	s.jobValidator.limits = s.jobSettings.Limits
+	s.jobs = newJobQueue(s.jobSettings, s.streamMean)
+	s.jobs.start(s.baseCtx)
+
 	r := chi.NewRouter()
+	r.Use(s.trackInFlight)
 	r.Use(middleware.RequestID)
+	r.Use(echoRequestID)
+	r.Use(tracingMiddleware)
 	r.Use(middleware.Logger)
-	r.Use(middleware.Timeout(60 * time.Second))
-	r.Use(validationMiddleware)
+	r.Use(metricsMiddleware(s.metrics))
+	r.Use(middleware.Timeout(s.timeout))
 
-	s := &RandomServer{
-		srv: http.Server{
-			Addr:    fmt.Sprintf(":%d", port),
-			Handler: r,
+	s.srv = http.Server{
+		Addr:    fmt.Sprintf(":%d", port),
+		Handler: r,
+		BaseContext: func(net.Listener) context.Context {
+			return s.baseCtx
 		},
-		generator:  generator,
-		calculator: calculator,
-		port:       port,
 	}
 
 	r.Route("/random", func(r chi.Router) {
-		r.Use(validationMiddleware)
-		r.Get("/mean", s.Mean)
+		if s.results != nil {
+			r.Use(s.uniqueRequestID)
+		}
+		r.With(validation).Get("/mean", s.Mean)
+		r.Post("/mean", s.MeanSpec)
 	})
 
+	r.Route("/v2/random", func(r chi.Router) {
+		if s.results != nil {
+			r.Use(s.uniqueRequestID)
+		}
+		r.With(validation).Get("/mean", s.MeanV2)
+	})
+
+	r.Route("/jobs", func(r chi.Router) {
+		r.Post("/", s.CreateJob)
+		r.Get("/{id}", s.GetJob)
+		r.Delete("/{id}", s.CancelJob)
+	})
+
+	if s.results != nil {
+		r.Route("/results", func(r chi.Router) {
+			r.Get("/", s.ListResults)
+			// request ids may contain slashes
+			r.Get("/*", s.GetResult)
+		})
+	}
+
+	r.Get("/status", s.Status)
+	r.Get("/healthz", s.Healthz)
+	r.Get("/readyz", s.Readyz)
+	r.Method(http.MethodGet, "/metrics", s.metrics.Handler())
+
 	return s
 }
 
-func (s *RandomServer) Run() {
+// Run serves requests until Stop is called and returns once draining
+// finished. It returns an error only when the server failed to start.
+func (s *RandomServer) Run() error {
+	l, err := net.Listen("tcp", s.srv.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on port %d: %w", s.port, err)
+	}
+	return s.serve(l)
+}
+
+func (s *RandomServer) serve(l net.Listener) error {
 	slog.Info("server is running", "timestamp", time.Now(), "port", s.port)
-	err := s.srv.ListenAndServe()
+	err := s.srv.Serve(l)
 	if err != nil && err != http.ErrServerClosed {
-		slog.Error("server error", "timestamp", time.Now(), "error", err)
+		return fmt.Errorf("server error: %w", err)
 	}
+	<-s.stopped
+	return nil
 }
 
-func (s *RandomServer) Stop() {
-	err := s.srv.Shutdown(context.Background())
-	if err != nil {
-		slog.Error("server shutting down error", "timestamp", time.Now(), "error", err)
+// Stop makes the server unready and keeps serving for the shutdown delay, so
+// that load balancers notice before connections are refused. It then stops
+// accepting requests and waits for in-flight ones until ctx is done. Requests
+// still running by then have their upstream calls cancelled and Stop returns
+// ErrDrainTimeout.
+func (s *RandomServer) Stop(ctx context.Context) error {
+	s.draining.Store(true)
+	defer s.stopOnce.Do(func() { close(s.stopped) })
+	defer s.cancelInFlight()
+
+	if s.shutdownDelay > 0 {
+		slog.Info("waiting before shutdown", "timestamp", time.Now(), "delay", s.shutdownDelay)
+		timer := time.NewTimer(s.shutdownDelay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+		}
+	}
+
+	err := s.srv.Shutdown(ctx)
+	if err == nil {
+		slog.Info("server drained", "timestamp", time.Now())
+		return nil
 	}
+	slog.Warn("cancelling in-flight requests", "timestamp", time.Now(), "error", err)
+	s.cancelInFlight()
+	s.srv.Close()
+	s.closeMu.Lock()
+	s.closed = true
+	s.closeMu.Unlock()
+	s.inFlight.Wait()
+	return fmt.Errorf("%w: %w", ErrDrainTimeout, err)
+}
+
+// echoRequestID returns the request id, generated or taken from the request,
+// in the X-Request-Id header so that recorded results can be looked up.
+func echoRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(middleware.RequestIDHeader, middleware.GetReqID(r.Context()))
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *RandomServer) trackInFlight(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.closeMu.Lock()
+		if s.closed {
+			s.closeMu.Unlock()
+			writeError(w, r, ErrShuttingDown)
+			return
+		}
+		s.inFlight.Add(1)
+		s.closeMu.Unlock()
+		defer s.inFlight.Done()
+		next.ServeHTTP(w, r)
+	})
 }
 
 func (s *RandomServer) Mean(w http.ResponseWriter, r *http.Request) {
-	requests, _ := paramPositiveInt(r, "requests")
-	length, _ := paramPositiveInt(r, "length")
+	params, _ := s.validator.parseMeanParams(r)
+	s.writeMean(w, r, params)
+}
 
-	res, err := s.doMean(r.Context(), requests, length)
+// MeanSpec calculates the sets listed in the JSON body of a POST request.
+func (s *RandomServer) MeanSpec(w http.ResponseWriter, r *http.Request) {
+	params, err := s.validator.parseMeanSpec(w, r)
 	if err != nil {
-		slog.Error("mean calculation", "timestamp", time.Now(), "error", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, err)
+		return
+	}
+	s.writeMean(w, r, params)
+}
+
+// writeMean answers with the array of results, or streams them when the
+// client accepts a streaming content type.
+func (s *RandomServer) writeMean(w http.ResponseWriter, r *http.Request, params meanParams) {
+	if contentType, ok := streamContentType(r); ok {
+		stream := newResultStream(w, r, contentType)
+		err := s.streamMean(r.Context(), params, stream.write)
+		if err != nil {
+			stream.fail(err)
+		}
+		return
+	}
+
+	res, err := s.doMean(r.Context(), params)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
@@ -89,31 +311,175 @@ func (s *RandomServer) Mean(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (s *RandomServer) doMean(ctx context.Context, requests, numbers int) ([]service.StdDevResult, error) {
-	pipe := make(chan []int, requests)
+// MeanResponse is the versioned envelope returned by /v2/random/mean.
+type MeanResponse struct {
+	RequestID string                 `json:"request_id"`
+	Requests  int                    `json:"requests"`
+	Length    int                    `json:"length"`
+	Source    string                 `json:"source"`
+	Sets      []service.StdDevResult `json:"sets"`
+	Combined  *service.StdDevResult  `json:"combined"`
+}
 
-	resultPipe := s.calculator.Calculate(pipe)
+func (s *RandomServer) MeanV2(w http.ResponseWriter, r *http.Request) {
+	params, _ := s.validator.parseMeanParams(r)
 
-	g, ctx := errgroup.WithContext(ctx)
-	for i := 0; i < requests; i++ {
+	res, err := s.doMean(r.Context(), params)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	payload := MeanResponse{
+		RequestID: middleware.GetReqID(r.Context()),
+		Requests:  params.requests,
+		Length:    params.length,
+		Source:    params.source,
+		Sets:      []service.StdDevResult{},
+	}
+	for _, singleRes := range res {
+		if singleRes.Combined {
+			combined := singleRes
+			payload.Combined = &combined
+			continue
+		}
+		payload.Sets = append(payload.Sets, singleRes)
+	}
+
+	err = json.NewEncoder(w).Encode(payload)
+	if err != nil {
+		slog.Error("failed to encode the payload", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (s *RandomServer) Status(w http.ResponseWriter, r *http.Request) {
+	payload := make(map[string]any, len(s.reporters))
+	for name, reporter := range s.reporters {
+		payload[name] = reporter.Status()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(payload)
+	if err != nil {
+		slog.Error("failed to encode the payload", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (s *RandomServer) doMean(ctx context.Context, params meanParams) ([]service.StdDevResult, error) {
+	var res []service.StdDevResult
+	err := s.streamMean(ctx, params, func(singleRes service.StdDevResult) error {
+		res = append(res, singleRes)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// streamMean passes every result to emit as soon as the calculator produces it,
+// the combined result last. It stops at the first error of a generator or emit.
+// The results of a successful calculation are recorded in the result store.
+func (s *RandomServer) streamMean(ctx context.Context, params meanParams, emit func(service.StdDevResult) error) error {
+	if s.results == nil {
+		return s.calculate(ctx, params, emit)
+	}
+	var results []service.StdDevResult
+	err := s.calculate(ctx, params, func(singleRes service.StdDevResult) error {
+		results = append(results, singleRes)
+		return emit(singleRes)
+	})
+	if err != nil {
+		return err
+	}
+	s.record(ctx, params, results)
+	return nil
+}
+
+func (s *RandomServer) calculate(ctx context.Context, params meanParams, emit func(service.StdDevResult) error) error {
+	s.metrics.MeanInFlight.Inc()
+	defer s.metrics.MeanInFlight.Dec()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	sets := params.setList()
+	pipe := make(chan []int, len(sets))
+
+	results := s.calculator.Calculate(ctx, pipe, params.kind, params.calculationFields())
+	defer func() {
+		go func() {
+			for range results {
+			}
+		}()
+	}()
+
+	// sets are generated concurrently but passed on in the order of the spec:
+	// a generated set takes the first free position of its shape, sets of the
+	// same shape being interchangeable, and waits for the turn of that
+	// position, which the preceding position hands over
+	var mu sync.Mutex
+	positions := make(map[setParams][]int)
+	turns := make([]chan struct{}, len(sets)+1)
+	for i, set := range sets {
+		positions[set] = append(positions[set], i)
+		turns[i] = make(chan struct{})
+	}
+	turns[len(sets)] = make(chan struct{})
+	close(turns[0])
+	g, gctx := errgroup.WithContext(ctx)
+	if params.concurrency > 0 {
+		g.SetLimit(params.concurrency)
+	}
+	for _, set := range sets {
+		generator := s.generators[set.source]
+		generated := s.metrics.GeneratedIntegers.WithLabelValues(set.source)
 		g.Go(func() error {
-			randomInts, err := s.generator.Integers(ctx, numbers)
+			randomInts, err := generator.Integers(gctx, set.length, set.min, set.max)
 			if err != nil {
 				return err
 			}
+			generated.Add(float64(len(randomInts)))
+			mu.Lock()
+			i := positions[set][0]
+			positions[set] = positions[set][1:]
+			mu.Unlock()
+			select {
+			case <-gctx.Done():
+				return gctx.Err()
+			case <-turns[i]:
+			}
 			pipe <- randomInts
+			close(turns[i+1])
 			return nil
 		})
 	}
-	err := g.Wait()
-	if err != nil {
-		return nil, fmt.Errorf("failed to calculate standard deviation: %v", err)
-	}
-	close(pipe)
+	genErr := make(chan error, 1)
+	go func() {
+		err := g.Wait()
+		close(pipe)
+		genErr <- err
+	}()
 
-	var res []service.StdDevResult
-	for singleRes := range resultPipe {
-		res = append(res, singleRes)
+	resultPipe := results
+	for resultPipe != nil || genErr != nil {
+		select {
+		case err := <-genErr:
+			if err != nil {
+				return fmt.Errorf("failed to calculate standard deviation: %w", err)
+			}
+			genErr = nil
+		case singleRes, ok := <-resultPipe:
+			if !ok {
+				resultPipe = nil
+				continue
+			}
+			err := emit(singleRes)
+			if err != nil {
+				return err
+			}
+		}
 	}
-	return res, nil
+	return nil
 }
@@ -1,21 +1,28 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/koenno/standard-deviation-service/breaker"
+	"github.com/koenno/standard-deviation-service/client"
+	"github.com/koenno/standard-deviation-service/client/randomorg"
+	"github.com/koenno/standard-deviation-service/random"
 	"github.com/koenno/standard-deviation-service/server/mocks"
 	"github.com/koenno/standard-deviation-service/service"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
-func TestShouldReturnInternalServiceErrorWhenGeneratorFails(t *testing.T) {
+func TestShouldReturnInternalServerErrorWhenGeneratorFailsUnexpectedly(t *testing.T) {
 	// given
 	port := 8080
 	requests, length := "1", "2"
@@ -26,11 +33,11 @@ func TestShouldReturnInternalServiceErrorWhenGeneratorFails(t *testing.T) {
 	calculatorMock := mocks.NewStdDevCalculator(t)
 	sut := NewRandomServer(generatorMock, calculatorMock, port)
 
-	generatorMock.EXPECT().Integers(mock.Anything, mock.Anything).Return(nil, errors.New("failure")).Once()
+	generatorMock.EXPECT().Integers(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("failure")).Once()
 
 	calcPipe := make(chan service.StdDevResult)
 	close(calcPipe)
-	calculatorMock.EXPECT().Calculate(mock.Anything).Return(calcPipe).Once()
+	calculatorMock.EXPECT().Calculate(mock.Anything, mock.Anything, service.Population, mock.Anything).Return(calcPipe).Once()
 
 	// when
 	sut.Mean(w, req)
@@ -38,12 +45,128 @@ func TestShouldReturnInternalServiceErrorWhenGeneratorFails(t *testing.T) {
 	// then
 	res := w.Result()
 	defer res.Body.Close()
-	data, err := io.ReadAll(res.Body)
+	var payload ErrorResponse
+	err := json.NewDecoder(res.Body).Decode(&payload)
 	assert.NoError(t, err)
-	assert.Empty(t, data)
+	assert.Equal(t, CodeInternal, payload.Code)
+	assert.Equal(t, "failed to calculate standard deviation: failure", payload.Message)
 	assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
 }
 
+func TestShouldMapGeneratorErrorsToStatuses(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:           "request initialization failure",
+			err:            fmt.Errorf("%w: %w", random.ErrInit, errors.New("bad url")),
+			expectedStatus: http.StatusInternalServerError,
+			expectedCode:   CodeGeneratorInit,
+		},
+		{
+			name:           "upstream unreachable",
+			err:            fmt.Errorf("%w: %w", random.ErrGenerator, fmt.Errorf("%w: %w", client.ErrSendRequest, errors.New("connection refused"))),
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedCode:   CodeUpstreamUnavailable,
+		},
+		{
+			name:           "circuit open",
+			err:            fmt.Errorf("%w: %w", random.ErrGenerator, breaker.ErrOpen),
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedCode:   CodeCircuitOpen,
+		},
+		{
+			name:           "quota exceeded",
+			err:            fmt.Errorf("%w: %w", random.ErrGenerator, randomorg.ErrQuotaExceeded),
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedCode:   CodeQuotaExceeded,
+		},
+		{
+			name:           "upstream timeout",
+			err:            fmt.Errorf("%w: %w", random.ErrGenerator, fmt.Errorf("%w: %w", client.ErrSendRequest, context.DeadlineExceeded)),
+			expectedStatus: http.StatusGatewayTimeout,
+			expectedCode:   CodeUpstreamTimeout,
+		},
+		{
+			name:           "rate limiter wait beyond deadline",
+			err:            fmt.Errorf("%w: %w", random.ErrGenerator, fmt.Errorf("%w: %w: %w", client.ErrRateLimit, context.DeadlineExceeded, errors.New("rate: Wait(n=1) would exceed context deadline"))),
+			expectedStatus: http.StatusGatewayTimeout,
+			expectedCode:   CodeUpstreamTimeout,
+		},
+		{
+			name:           "rate limiter wait cancelled by client",
+			err:            fmt.Errorf("%w: %w", random.ErrGenerator, fmt.Errorf("%w: %w", client.ErrRateLimit, context.Canceled)),
+			expectedStatus: StatusClientClosedRequest,
+			expectedCode:   CodeClientClosed,
+		},
+		{
+			name:           "request cancelled by client",
+			err:            fmt.Errorf("%w: %w", random.ErrGenerator, fmt.Errorf("%w: %w", client.ErrSendRequest, context.Canceled)),
+			expectedStatus: StatusClientClosedRequest,
+			expectedCode:   CodeClientClosed,
+		},
+		{
+			name:           "unwrapped cancellation",
+			err:            context.Canceled,
+			expectedStatus: StatusClientClosedRequest,
+			expectedCode:   CodeClientClosed,
+		},
+		{
+			name:           "upstream invalid status",
+			err:            fmt.Errorf("%w: %w", random.ErrGenerator, fmt.Errorf("%w: status code 503", client.ErrResponse)),
+			expectedStatus: http.StatusBadGateway,
+			expectedCode:   CodeUpstreamResponse,
+		},
+		{
+			name:           "generator failure",
+			err:            fmt.Errorf("%w: %w", random.ErrGenerator, errors.New("failure")),
+			expectedStatus: http.StatusBadGateway,
+			expectedCode:   CodeGenerator,
+		},
+		{
+			name:           "malformed items",
+			err:            fmt.Errorf("%w (integers): %w", random.ErrItems, errors.New("not a number")),
+			expectedStatus: http.StatusBadGateway,
+			expectedCode:   CodeUpstreamItems,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// given
+			port := 8080
+			req := httptest.NewRequest(http.MethodGet, "/random/mean?requests=1&length=2", nil)
+			w := httptest.NewRecorder()
+			generatorMock := mocks.NewRandomIntegerGenerator(t)
+			calculatorMock := mocks.NewStdDevCalculator(t)
+			sut := NewRandomServer(generatorMock, calculatorMock, port)
+
+			generatorMock.EXPECT().Integers(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, test.err).Once()
+
+			calcPipe := make(chan service.StdDevResult)
+			close(calcPipe)
+			calculatorMock.EXPECT().Calculate(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(calcPipe).Once()
+
+			// when
+			sut.srv.Handler.ServeHTTP(w, req)
+
+			// then
+			res := w.Result()
+			defer res.Body.Close()
+			var payload ErrorResponse
+			err := json.NewDecoder(res.Body).Decode(&payload)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedStatus, res.StatusCode)
+			assert.Equal(t, test.expectedCode, payload.Code)
+			assert.NotEmpty(t, payload.RequestID)
+			assert.Empty(t, payload.Parameter)
+			assert.Equal(t, "application/json", res.Header.Get("Content-Type"))
+		})
+	}
+}
+
 func TestShouldReturnStandardDeviationCalculations(t *testing.T) {
 	// given
 	port := 8080
@@ -56,7 +179,7 @@ func TestShouldReturnStandardDeviationCalculations(t *testing.T) {
 	sut := NewRandomServer(generatorMock, calculatorMock, port)
 
 	genRes := []int{0, 1, 2, 3, 4}
-	generatorMock.EXPECT().Integers(mock.Anything, mock.Anything).Return(genRes, nil).Twice()
+	generatorMock.EXPECT().Integers(mock.Anything, 5, defaultMin, defaultMax).Return(genRes, nil).Twice()
 
 	expectedResult := []service.StdDevResult{
 		{
@@ -79,7 +202,7 @@ func TestShouldReturnStandardDeviationCalculations(t *testing.T) {
 		calcPipe <- expectedResult[1]
 		calcPipe <- expectedResult[2]
 	}()
-	calculatorMock.EXPECT().Calculate(mock.Anything).Run(func(input <-chan []int) {
+	calculatorMock.EXPECT().Calculate(mock.Anything, mock.Anything, service.Population, []service.Field{service.FieldData}).Run(func(ctx context.Context, input <-chan []int, kind service.Kind, fields []service.Field) {
 		go func() {
 			for _ = range input {
 			}
@@ -98,3 +221,391 @@ func TestShouldReturnStandardDeviationCalculations(t *testing.T) {
 	assert.ElementsMatch(t, expectedResult, stddevResult)
 	assert.Equal(t, http.StatusOK, res.StatusCode)
 }
+
+func TestShouldPassRangeToGenerator(t *testing.T) {
+	// given
+	port := 8080
+	requests, length, min, max := "1", "3", "-20", "20"
+	URL := fmt.Sprintf("/random/mean?requests=%s&length=%s&min=%s&max=%s", requests, length, min, max)
+	req := httptest.NewRequest(http.MethodGet, URL, nil)
+	w := httptest.NewRecorder()
+	generatorMock := mocks.NewRandomIntegerGenerator(t)
+	calculatorMock := mocks.NewStdDevCalculator(t)
+	sut := NewRandomServer(generatorMock, calculatorMock, port)
+
+	genRes := []int{-20, 0, 20}
+	generatorMock.EXPECT().Integers(mock.Anything, 3, -20, 20).Return(genRes, nil).Once()
+
+	calcPipe := make(chan service.StdDevResult)
+	close(calcPipe)
+	calculatorMock.EXPECT().Calculate(mock.Anything, mock.Anything, service.Population, mock.Anything).Return(calcPipe).Once()
+
+	// when
+	sut.Mean(w, req)
+
+	// then
+	res := w.Result()
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+}
+
+func TestShouldPassConfiguredDefaultRangeToGenerator(t *testing.T) {
+	// given
+	port := 8080
+	req := httptest.NewRequest(http.MethodGet, "/random/mean?requests=1&length=3", nil)
+	w := httptest.NewRecorder()
+	generatorMock := mocks.NewRandomIntegerGenerator(t)
+	calculatorMock := mocks.NewStdDevCalculator(t)
+	sut := NewRandomServer(generatorMock, calculatorMock, port, WithDefaultRange(0, 99))
+
+	generatorMock.EXPECT().Integers(mock.Anything, 3, 0, 99).Return([]int{0, 50, 99}, nil).Once()
+
+	calcPipe := make(chan service.StdDevResult)
+	close(calcPipe)
+	calculatorMock.EXPECT().Calculate(mock.Anything, mock.Anything, service.Population, mock.Anything).Return(calcPipe).Once()
+
+	// when
+	sut.srv.Handler.ServeHTTP(w, req)
+
+	// then
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+}
+
+func TestShouldPassKindToCalculator(t *testing.T) {
+	// given
+	port := 8080
+	URL := "/random/mean?requests=1&length=3&kind=sample"
+	req := httptest.NewRequest(http.MethodGet, URL, nil)
+	w := httptest.NewRecorder()
+	generatorMock := mocks.NewRandomIntegerGenerator(t)
+	calculatorMock := mocks.NewStdDevCalculator(t)
+	sut := NewRandomServer(generatorMock, calculatorMock, port)
+
+	genRes := []int{1, 2, 3}
+	generatorMock.EXPECT().Integers(mock.Anything, 3, defaultMin, defaultMax).Return(genRes, nil).Once()
+
+	calcPipe := make(chan service.StdDevResult)
+	close(calcPipe)
+	calculatorMock.EXPECT().Calculate(mock.Anything, mock.Anything, service.Sample, mock.Anything).Return(calcPipe).Once()
+
+	// when
+	sut.Mean(w, req)
+
+	// then
+	res := w.Result()
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+}
+
+func TestShouldPassFieldsToCalculator(t *testing.T) {
+	// given
+	port := 8080
+	URL := "/random/mean?requests=1&length=3&fields=mean,median"
+	req := httptest.NewRequest(http.MethodGet, URL, nil)
+	w := httptest.NewRecorder()
+	generatorMock := mocks.NewRandomIntegerGenerator(t)
+	calculatorMock := mocks.NewStdDevCalculator(t)
+	sut := NewRandomServer(generatorMock, calculatorMock, port)
+
+	genRes := []int{1, 2, 3}
+	generatorMock.EXPECT().Integers(mock.Anything, 3, defaultMin, defaultMax).Return(genRes, nil).Once()
+
+	calcPipe := make(chan service.StdDevResult)
+	close(calcPipe)
+	expectedFields := []service.Field{service.FieldMean, service.FieldMedian, service.FieldData}
+	calculatorMock.EXPECT().Calculate(mock.Anything, mock.Anything, service.Population, expectedFields).Return(calcPipe).Once()
+
+	// when
+	sut.Mean(w, req)
+
+	// then
+	res := w.Result()
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+}
+
+func TestShouldLimitSetsGeneratedAtOnce(t *testing.T) {
+	// given
+	port := 8080
+	req := httptest.NewRequest(http.MethodGet, "/random/mean?requests=8&length=2", nil)
+	w := httptest.NewRecorder()
+	generatorMock := mocks.NewRandomIntegerGenerator(t)
+	limits := DefaultLimits()
+	limits.MaxConcurrency = 2
+	sut := NewRandomServer(generatorMock, service.NewStdDevService(), port, WithLimits(limits))
+
+	var inFlight, maxInFlight atomic.Int32
+	generatorMock.EXPECT().Integers(mock.Anything, 2, defaultMin, defaultMax).RunAndReturn(func(ctx context.Context, quantity, min, max int) ([]int, error) {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			seen := maxInFlight.Load()
+			if current <= seen || maxInFlight.CompareAndSwap(seen, current) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		return []int{1, 3}, nil
+	}).Times(8)
+
+	// when
+	sut.srv.Handler.ServeHTTP(w, req)
+
+	// then
+	res := w.Result()
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, int32(2), maxInFlight.Load())
+}
+
+func TestShouldReturnLabeledEnvelopeInV2(t *testing.T) {
+	// given
+	port := 8080
+	URL := "/v2/random/mean?requests=2&length=5"
+	req := httptest.NewRequest(http.MethodGet, URL, nil)
+	w := httptest.NewRecorder()
+	generatorMock := mocks.NewRandomIntegerGenerator(t)
+	calculatorMock := mocks.NewStdDevCalculator(t)
+	sut := NewRandomServer(generatorMock, calculatorMock, port)
+
+	genRes := []int{0, 1, 2, 3, 4}
+	generatorMock.EXPECT().Integers(mock.Anything, 5, defaultMin, defaultMax).Return(genRes, nil).Twice()
+
+	setResult := service.StdDevResult{StdDev: 1, Kind: service.Population, Data: genRes}
+	combinedResult := service.StdDevResult{StdDev: 2, Kind: service.Population, Data: append(genRes, genRes...), Combined: true}
+	calcPipe := make(chan service.StdDevResult)
+	go func() {
+		defer close(calcPipe)
+		calcPipe <- setResult
+		calcPipe <- setResult
+		calcPipe <- combinedResult
+	}()
+	calculatorMock.EXPECT().Calculate(mock.Anything, mock.Anything, service.Population, []service.Field{service.FieldData}).Run(func(ctx context.Context, input <-chan []int, kind service.Kind, fields []service.Field) {
+		go func() {
+			for _ = range input {
+			}
+		}()
+	}).Return(calcPipe).Once()
+
+	// when
+	sut.srv.Handler.ServeHTTP(w, req)
+
+	// then
+	res := w.Result()
+	defer res.Body.Close()
+	var payload MeanResponse
+	err := json.NewDecoder(res.Body).Decode(&payload)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.NotEmpty(t, payload.RequestID)
+	assert.Equal(t, 2, payload.Requests)
+	assert.Equal(t, 5, payload.Length)
+	assert.Equal(t, "random.org", payload.Source)
+	assert.Equal(t, []service.StdDevResult{setResult, setResult}, payload.Sets)
+	combinedResult.Combined = false
+	assert.Equal(t, &combinedResult, payload.Combined)
+}
+
+func TestShouldUseGeneratorSelectedBySource(t *testing.T) {
+	// given
+	port := 8080
+	URL := "/v2/random/mean?requests=1&length=3&source=local"
+	req := httptest.NewRequest(http.MethodGet, URL, nil)
+	w := httptest.NewRecorder()
+	defaultGeneratorMock := mocks.NewRandomIntegerGenerator(t)
+	localGeneratorMock := mocks.NewRandomIntegerGenerator(t)
+	calculatorMock := mocks.NewStdDevCalculator(t)
+	sut := NewRandomServer(defaultGeneratorMock, calculatorMock, port, WithGenerator("local", localGeneratorMock))
+
+	genRes := []int{1, 2, 3}
+	localGeneratorMock.EXPECT().Integers(mock.Anything, 3, defaultMin, defaultMax).Return(genRes, nil).Once()
+
+	calcPipe := make(chan service.StdDevResult)
+	close(calcPipe)
+	calculatorMock.EXPECT().Calculate(mock.Anything, mock.Anything, service.Population, mock.Anything).Return(calcPipe).Once()
+
+	// when
+	sut.srv.Handler.ServeHTTP(w, req)
+
+	// then
+	res := w.Result()
+	defer res.Body.Close()
+	var payload MeanResponse
+	err := json.NewDecoder(res.Body).Decode(&payload)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "local", payload.Source)
+	defaultGeneratorMock.AssertNotCalled(t, "Integers")
+}
+
+func TestShouldReturnComponentsStatus(t *testing.T) {
+	// given
+	port := 8080
+	req := httptest.NewRequest(http.MethodGet, "/status", nil)
+	w := httptest.NewRecorder()
+	generatorMock := mocks.NewRandomIntegerGenerator(t)
+	calculatorMock := mocks.NewStdDevCalculator(t)
+	reporterMock := mocks.NewStatusReporter(t)
+	sut := NewRandomServer(generatorMock, calculatorMock, port, WithStatusReporter("breaker", reporterMock))
+
+	reporterMock.EXPECT().Status().Return(map[string]string{"state": "open"}).Once()
+
+	// when
+	sut.srv.Handler.ServeHTTP(w, req)
+
+	// then
+	res := w.Result()
+	defer res.Body.Close()
+	var payload map[string]map[string]string
+	err := json.NewDecoder(res.Body).Decode(&payload)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, map[string]map[string]string{"breaker": {"state": "open"}}, payload)
+}
+
+func startServer(t *testing.T, sut *RandomServer) (string, <-chan error) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- sut.serve(l)
+	}()
+	return "http://" + l.Addr().String(), runErr
+}
+
+func TestShouldDrainInFlightRequestsBeforeStopping(t *testing.T) {
+	// given
+	port := 8080
+	generatorMock := mocks.NewRandomIntegerGenerator(t)
+	calculatorMock := mocks.NewStdDevCalculator(t)
+	sut := NewRandomServer(generatorMock, calculatorMock, port)
+	URL, runErr := startServer(t, sut)
+
+	called := make(chan struct{})
+	release := make(chan struct{})
+	generatorMock.EXPECT().Integers(mock.Anything, 2, defaultMin, defaultMax).RunAndReturn(func(ctx context.Context, quantity, min, max int) ([]int, error) {
+		close(called)
+		<-release
+		return []int{1, 3}, nil
+	}).Once()
+	calcPipe := make(chan service.StdDevResult, 1)
+	calcPipe <- service.StdDevResult{StdDev: 1, Data: []int{1, 3}}
+	close(calcPipe)
+	calculatorMock.EXPECT().Calculate(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(calcPipe).Once()
+
+	resStatus := make(chan int, 1)
+	go func() {
+		res, err := http.Get(URL + "/random/mean?requests=1&length=2")
+		assert.NoError(t, err)
+		res.Body.Close()
+		resStatus <- res.StatusCode
+	}()
+	<-called
+
+	// when
+	stopErr := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		stopErr <- sut.Stop(ctx)
+	}()
+	assert.Eventually(t, func() bool {
+		status, _ := getHealth(t, sut, "/readyz")
+		return status == http.StatusServiceUnavailable
+	}, time.Second, time.Millisecond)
+	close(release)
+
+	// then
+	assert.NoError(t, <-stopErr)
+	assert.NoError(t, <-runErr)
+	assert.Equal(t, http.StatusOK, <-resStatus)
+}
+
+func TestShouldCancelInFlightRequestsAfterDrainDeadline(t *testing.T) {
+	// given
+	port := 8080
+	generatorMock := mocks.NewRandomIntegerGenerator(t)
+	calculatorMock := mocks.NewStdDevCalculator(t)
+	sut := NewRandomServer(generatorMock, calculatorMock, port)
+	URL, runErr := startServer(t, sut)
+
+	called := make(chan struct{})
+	upstreamErr := make(chan error, 1)
+	generatorMock.EXPECT().Integers(mock.Anything, 2, defaultMin, defaultMax).RunAndReturn(func(ctx context.Context, quantity, min, max int) ([]int, error) {
+		close(called)
+		<-ctx.Done()
+		upstreamErr <- ctx.Err()
+		return nil, ctx.Err()
+	}).Once()
+	calculatorMock.EXPECT().Calculate(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(make(chan service.StdDevResult)).Once()
+
+	go func() {
+		res, err := http.Get(URL + "/random/mean?requests=1&length=2")
+		if err == nil {
+			res.Body.Close()
+		}
+	}()
+	<-called
+
+	// when
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := sut.Stop(ctx)
+
+	// then
+	assert.ErrorIs(t, err, ErrDrainTimeout)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorIs(t, <-upstreamErr, context.Canceled)
+	assert.NoError(t, <-runErr)
+	w := httptest.NewRecorder()
+	sut.srv.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	var payload ErrorResponse
+	assert.NoError(t, json.NewDecoder(w.Result().Body).Decode(&payload))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, CodeShuttingDown, payload.Code)
+}
+
+func TestShouldKeepServingDuringShutdownDelay(t *testing.T) {
+	// given
+	port := 8080
+	delay := 300 * time.Millisecond
+	sut := NewRandomServer(mocks.NewRandomIntegerGenerator(t), mocks.NewStdDevCalculator(t), port, WithShutdownDelay(delay))
+	URL, runErr := startServer(t, sut)
+
+	// when
+	start := time.Now()
+	stopErr := make(chan error, 1)
+	go func() {
+		stopErr <- sut.Stop(context.Background())
+	}()
+	assert.Eventually(t, func() bool {
+		status, _ := getHealth(t, sut, "/readyz")
+		return status == http.StatusServiceUnavailable
+	}, time.Second, time.Millisecond)
+	res, err := http.Get(URL + "/healthz")
+
+	// then
+	if assert.NoError(t, err) {
+		res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	}
+	assert.NoError(t, <-stopErr)
+	assert.GreaterOrEqual(t, time.Since(start), delay)
+	assert.NoError(t, <-runErr)
+}
+
+func TestShouldReturnErrorWhenPortIsTaken(t *testing.T) {
+	// given
+	l, err := net.Listen("tcp", ":0")
+	assert.NoError(t, err)
+	defer l.Close()
+	port := l.Addr().(*net.TCPAddr).Port
+	sut := NewRandomServer(mocks.NewRandomIntegerGenerator(t), mocks.NewStdDevCalculator(t), port)
+
+	// when
+	err = sut.Run()
+
+	// then
+	assert.ErrorContains(t, err, fmt.Sprintf("failed to listen on port %d", port))
+}
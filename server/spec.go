@@ -0,0 +1,166 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/koenno/standard-deviation-service/service"
+)
+
+// maxSpecBytes caps the size of a POST /random/mean body.
+const maxSpecBytes = 1 << 20
+
+var (
+	ErrInvalidBody          = errors.New("invalid request body")
+	ErrParamEmpty           = errors.New("parameter must not be empty")
+	ErrParamLengthsTooLarge = errors.New("parameter lengths must not add up to more than")
+)
+
+// MeanSpec is the body of POST /random/mean. Kind defaults to population.
+type MeanSpec struct {
+	Kind   service.Kind    `json:"kind,omitempty"`
+	Fields []service.Field `json:"fields,omitempty"`
+	Sets   []SetSpec       `json:"sets"`
+}
+
+// SetSpec describes a single set. Omitted min, max and source take the
+// same defaults as the query parameters of GET /random/mean.
+type SetSpec struct {
+	Length int    `json:"length"`
+	Min    *int   `json:"min,omitempty"`
+	Max    *int   `json:"max,omitempty"`
+	Source string `json:"source,omitempty"`
+}
+
+// SpecError reports every invalid field of a MeanSpec at once.
+type SpecError struct {
+	Errors []*ParamError
+}
+
+func (e *SpecError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (e *SpecError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, err := range e.Errors {
+		errs[i] = err
+	}
+	return errs
+}
+
+func (v validator) parseMeanSpec(w http.ResponseWriter, r *http.Request) (meanParams, error) {
+	var spec MeanSpec
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxSpecBytes))
+	dec.DisallowUnknownFields()
+	err := dec.Decode(&spec)
+	if err == nil && dec.More() {
+		err = errors.New("unexpected data after the JSON document")
+	}
+	if errors.Is(err, io.EOF) {
+		err = errors.New("empty body")
+	}
+	if err != nil {
+		return meanParams{}, fmt.Errorf("%w: %w", ErrInvalidBody, err)
+	}
+	return v.validateMeanSpec(spec)
+}
+
+func (v validator) validateMeanSpec(spec MeanSpec) (meanParams, error) {
+	var errs []*ParamError
+	invalid := func(field string, err error) {
+		errs = append(errs, &ParamError{Param: field, Err: err})
+	}
+
+	params := meanParams{
+		concurrency: v.limits.MaxConcurrency,
+		kind:        spec.Kind,
+		sets:        make([]setParams, 0, len(spec.Sets)),
+	}
+	switch spec.Kind {
+	case "":
+		params.kind = service.Population
+	case service.Population, service.Sample:
+	default:
+		invalid("kind", ErrParamUnknownKind)
+	}
+	for i, field := range spec.Fields {
+		if !service.ValidField(field) {
+			invalid(fmt.Sprintf("fields[%d]", i), ErrParamUnknownField)
+		}
+	}
+	params.fields = spec.Fields
+
+	if len(spec.Sets) == 0 {
+		invalid("sets", ErrParamEmpty)
+	}
+	if v.limits.MaxRequests > 0 && len(spec.Sets) > v.limits.MaxRequests {
+		invalid("sets", fmt.Errorf("%w %d", ErrParamTooLarge, v.limits.MaxRequests))
+	}
+	total := 0
+	for i, set := range spec.Sets {
+		name := fmt.Sprintf("sets[%d]", i)
+		if set.Length <= 0 {
+			invalid(name+".length", ErrParamNotPositiveInteger)
+		} else if v.limits.MaxLength > 0 && set.Length > v.limits.MaxLength {
+			invalid(name+".length", fmt.Errorf("%w %d", ErrParamTooLarge, v.limits.MaxLength))
+		}
+		if set.Length > 0 {
+			// saturate rather than overflow
+			total = min(total, math.MaxInt-set.Length) + set.Length
+		}
+
+		min, max := v.defaultMin, v.defaultMax
+		if set.Min != nil {
+			min = *set.Min
+		}
+		if set.Max != nil {
+			max = *set.Max
+		}
+		boundsOK := true
+		if min < lowerBound || min > upperBound {
+			invalid(name+".min", ErrParamOutOfBounds)
+			boundsOK = false
+		}
+		if max < lowerBound || max > upperBound {
+			invalid(name+".max", ErrParamOutOfBounds)
+			boundsOK = false
+		}
+		if boundsOK && min >= max {
+			invalid(name+".min", ErrParamMinNotLessThanMax)
+		}
+
+		source := set.Source
+		if source == "" {
+			source = v.defaultSource
+		}
+		if !slices.Contains(v.sources, source) {
+			invalid(name+".source", fmt.Errorf("%w %s", ErrParamUnknownSource, strings.Join(v.sources, ", ")))
+		}
+
+		params.sets = append(params.sets, setParams{
+			length: set.Length,
+			min:    min,
+			max:    max,
+			source: source,
+		})
+	}
+	if v.limits.MaxTotal > 0 && total > v.limits.MaxTotal {
+		invalid("sets", fmt.Errorf("%w %d", ErrParamLengthsTooLarge, v.limits.MaxTotal))
+	}
+
+	if len(errs) > 0 {
+		return meanParams{}, &SpecError{Errors: errs}
+	}
+	return params, nil
+}
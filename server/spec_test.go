@@ -0,0 +1,207 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/koenno/standard-deviation-service/server/mocks"
+	"github.com/koenno/standard-deviation-service/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func postSpec(sut *RandomServer, body string) *http.Response {
+	req := httptest.NewRequest(http.MethodPost, "/random/mean", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	sut.srv.Handler.ServeHTTP(w, req)
+	return w.Result()
+}
+
+func TestShouldCalculateSetsOfMeanSpec(t *testing.T) {
+	// given
+	port := 8080
+	generatorMock := mocks.NewRandomIntegerGenerator(t)
+	localMock := mocks.NewRandomIntegerGenerator(t)
+	sut := NewRandomServer(generatorMock, service.NewStdDevService(), port, WithGenerator("local", localMock))
+
+	generatorMock.EXPECT().Integers(mock.Anything, 2, defaultMin, 5).Return([]int{1, 3}, nil).Once()
+	localMock.EXPECT().Integers(mock.Anything, 3, -10, 10).Return([]int{-6, 0, 6}, nil).Once()
+	body := `{
+		"kind": "sample",
+		"fields": ["mean", "count", "data"],
+		"sets": [
+			{"length": 2, "max": 5},
+			{"length": 3, "min": -10, "max": 10, "source": "local"}
+		]
+	}`
+
+	// when
+	res := postSpec(sut, body)
+
+	// then
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	var results []service.StdDevResult
+	err := json.NewDecoder(res.Body).Decode(&results)
+	assert.NoError(t, err)
+	if assert.Len(t, results, 3) {
+		assert.Equal(t, [][]int{{1, 3}, {-6, 0, 6}}, [][]int{results[0].Data, results[1].Data})
+		assert.ElementsMatch(t, []int{1, 3, -6, 0, 6}, results[2].Data)
+		for _, singleRes := range results {
+			assert.Equal(t, service.Sample, singleRes.Kind)
+			assert.NotNil(t, singleRes.Mean)
+			assert.NotNil(t, singleRes.Count)
+			assert.Nil(t, singleRes.Median)
+		}
+	}
+}
+
+func TestShouldReturnResultsInOrderOfSetsWhateverOrderTheyAreGeneratedIn(t *testing.T) {
+	// given
+	port := 8080
+	generatorMock := mocks.NewRandomIntegerGenerator(t)
+	sut := NewRandomServer(generatorMock, service.NewStdDevService(), port)
+
+	lastGenerated := make(chan struct{})
+	generatorMock.EXPECT().Integers(mock.Anything, 4, defaultMin, defaultMax).Run(func(ctx context.Context, length, min, max int) {
+		<-lastGenerated
+	}).Return([]int{1, 2, 3, 4}, nil).Once()
+	generatorMock.EXPECT().Integers(mock.Anything, 1, defaultMin, defaultMax).Return([]int{5}, nil).Once()
+	generatorMock.EXPECT().Integers(mock.Anything, 2, defaultMin, defaultMax).Run(func(ctx context.Context, length, min, max int) {
+		close(lastGenerated)
+	}).Return([]int{6, 7}, nil).Once()
+	body := `{"fields": ["count", "data"], "sets": [{"length": 4}, {"length": 1}, {"length": 2}]}`
+
+	// when
+	res := postSpec(sut, body)
+
+	// then
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	var results []service.StdDevResult
+	err := json.NewDecoder(res.Body).Decode(&results)
+	assert.NoError(t, err)
+	if assert.Len(t, results, 4) {
+		assert.Equal(t, [][]int{{1, 2, 3, 4}, {5}, {6, 7}}, [][]int{results[0].Data, results[1].Data, results[2].Data})
+		assert.Equal(t, []int{1, 2, 3, 4, 5, 6, 7}, results[3].Data)
+		assert.Equal(t, 7, *results[3].Count)
+	}
+}
+
+func TestShouldRejectInvalidMeanSpec(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedCode   string
+		expectedFields []FieldError
+	}{
+		{
+			name:           "empty body",
+			body:           "",
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   CodeInvalidBody,
+		},
+		{
+			name:           "malformed document",
+			body:           `{"sets": [`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   CodeInvalidBody,
+		},
+		{
+			name:           "unknown field",
+			body:           `{"sets": [{"length": 1}], "requests": 2}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   CodeInvalidBody,
+		},
+		{
+			name:           "trailing document",
+			body:           `{"sets": [{"length": 1}]} {}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   CodeInvalidBody,
+		},
+		{
+			name:           "body too large",
+			body:           `{"sets": [` + strings.Repeat(`{"length": 1},`, maxSpecBytes/10) + `{"length": 1}]}`,
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedCode:   CodeLimitExceeded,
+		},
+		{
+			name:           "missing sets",
+			body:           `{"kind": "sample"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   CodeInvalidParameter,
+			expectedFields: []FieldError{
+				{Field: "sets", Message: "parameter must not be empty"},
+			},
+		},
+		{
+			name: "every invalid field",
+			body: `{
+				"kind": "median",
+				"fields": ["mean", "mode"],
+				"sets": [
+					{"length": 0, "min": 5, "max": 5},
+					{"length": 1, "min": -2000000000, "source": "dice"}
+				]
+			}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   CodeInvalidParameter,
+			expectedFields: []FieldError{
+				{Field: "kind", Message: "parameter must be either population or sample"},
+				{Field: "fields[1]", Message: "parameter must be a comma-separated list of: " + fieldNames()},
+				{Field: "sets[0].length", Message: "parameter must be a positive integer"},
+				{Field: "sets[0].min", Message: "parameter must be less than max"},
+				{Field: "sets[1].min", Message: "parameter must be within [-1000000000, 1000000000]"},
+				{Field: "sets[1].source", Message: "parameter must be one of: random.org"},
+			},
+		},
+		{
+			name:           "limits exceeded",
+			body:           `{"sets": [{"length": 60000}, {"length": 50000}]}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedCode:   CodeLimitExceeded,
+			expectedFields: []FieldError{
+				{Field: "sets[0].length", Message: "parameter must not exceed 10000"},
+				{Field: "sets[1].length", Message: "parameter must not exceed 10000"},
+				{Field: "sets", Message: "parameter lengths must not add up to more than 100000"},
+			},
+		},
+		{
+			name:           "lengths overflowing int",
+			body:           `{"sets": [{"length": 9223372036854775807}, {"length": 9223372036854775807}]}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedCode:   CodeLimitExceeded,
+			expectedFields: []FieldError{
+				{Field: "sets[0].length", Message: "parameter must not exceed 10000"},
+				{Field: "sets[1].length", Message: "parameter must not exceed 10000"},
+				{Field: "sets", Message: "parameter lengths must not add up to more than 100000"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// given
+			port := 8080
+			sut := NewRandomServer(mocks.NewRandomIntegerGenerator(t), mocks.NewStdDevCalculator(t), port)
+
+			// when
+			res := postSpec(sut, test.body)
+
+			// then
+			defer res.Body.Close()
+			assert.Equal(t, test.expectedStatus, res.StatusCode)
+			var payload ErrorResponse
+			err := json.NewDecoder(res.Body).Decode(&payload)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedCode, payload.Code)
+			assert.Equal(t, test.expectedFields, payload.Fields)
+			assert.Empty(t, payload.Parameter)
+		})
+	}
+}
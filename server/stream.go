@@ -0,0 +1,174 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/koenno/standard-deviation-service/service"
+	"golang.org/x/exp/slog"
+)
+
+const (
+	ContentTypeNDJSON      = "application/x-ndjson"
+	ContentTypeEventStream = "text/event-stream"
+)
+
+// Names of the Server-Sent Events written by a text/event-stream response.
+const (
+	EventSet      = "set"
+	EventCombined = "combined"
+	EventError    = "error"
+)
+
+// streamContentType returns the streaming media type the Accept header
+// prefers, if it prefers one strictly over application/json. Streaming types
+// of equal preference are chosen in the order they are listed.
+func streamContentType(r *http.Request) (string, bool) {
+	ranges := parseAccept(r.Header.Get("Accept"))
+	jsonQ, _ := quality(ranges, "application/json")
+	var best string
+	bestQ, bestIndex := 0.0, 0
+	for _, mediaType := range []string{ContentTypeNDJSON, ContentTypeEventStream} {
+		q, index := quality(ranges, mediaType)
+		if q > bestQ || q == bestQ && q > 0 && index < bestIndex {
+			best, bestQ, bestIndex = mediaType, q, index
+		}
+	}
+	if bestQ <= jsonQ {
+		return "", false
+	}
+	return best, true
+}
+
+// acceptRange is a media range of an Accept header with its q-value.
+type acceptRange struct {
+	mediaType string
+	q         float64
+}
+
+// parseAccept returns the media ranges of an Accept header in the order they
+// are listed, skipping malformed ones.
+func parseAccept(header string) []acceptRange {
+	var ranges []acceptRange
+	for _, accepted := range strings.Split(header, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			q, err = strconv.ParseFloat(value, 64)
+			if err != nil || q < 0 || q > 1 {
+				continue
+			}
+		}
+		ranges = append(ranges, acceptRange{mediaType: mediaType, q: q})
+	}
+	return ranges
+}
+
+// quality returns the q-value of the most specific range matching mediaType,
+// with the index of that range, or 0 when no range matches.
+func quality(ranges []acceptRange, mediaType string) (float64, int) {
+	mainType, _, _ := strings.Cut(mediaType, "/")
+	q, index, specificity := 0.0, -1, 0
+	for i, r := range ranges {
+		var s int
+		switch r.mediaType {
+		case mediaType:
+			s = 3
+		case mainType + "/*":
+			s = 2
+		case "*/*":
+			s = 1
+		default:
+			continue
+		}
+		if s > specificity {
+			q, index, specificity = r.q, i, s
+		}
+	}
+	return q, index
+}
+
+// resultStream writes results one at a time and flushes each. The response
+// status is committed with the first result, so that a failure before it is
+// still reported with a proper error status.
+type resultStream struct {
+	w           http.ResponseWriter
+	r           *http.Request
+	rc          *http.ResponseController
+	contentType string
+	started     bool
+}
+
+func newResultStream(w http.ResponseWriter, r *http.Request, contentType string) *resultStream {
+	return &resultStream{
+		w:           w,
+		r:           r,
+		rc:          http.NewResponseController(w),
+		contentType: contentType,
+	}
+}
+
+func (s *resultStream) write(res service.StdDevResult) error {
+	if !s.started {
+		s.started = true
+		s.w.Header().Set("Content-Type", s.contentType)
+		s.w.Header().Set("Cache-Control", "no-cache")
+		s.w.WriteHeader(http.StatusOK)
+	}
+	event := EventSet
+	if res.Combined {
+		event = EventCombined
+	}
+	return s.send(event, res)
+}
+
+// fail reports err with an error status, or as the last item when results
+// have already been written.
+func (s *resultStream) fail(err error) {
+	if !s.started {
+		writeError(s.w, s.r, err)
+		return
+	}
+	if s.r.Context().Err() != nil {
+		slog.Info("client cancelled the stream", "timestamp", time.Now(), "error", err)
+		return
+	}
+	_, code := classifyError(err)
+	sendErr := s.send(EventError, ErrorResponse{
+		Code:      code,
+		Message:   err.Error(),
+		RequestID: middleware.GetReqID(s.r.Context()),
+	})
+	if sendErr != nil {
+		slog.Error("failed to write the stream error", "error", sendErr)
+	}
+}
+
+func (s *resultStream) send(event string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode the payload: %w", err)
+	}
+	if s.contentType == ContentTypeEventStream {
+		_, err = fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", event, data)
+	} else {
+		_, err = fmt.Fprintf(s.w, "%s\n", data)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write the stream: %w", err)
+	}
+	err = s.rc.Flush()
+	if err != nil {
+		return fmt.Errorf("failed to flush the stream: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,245 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/koenno/standard-deviation-service/breaker"
+	"github.com/koenno/standard-deviation-service/server/mocks"
+	"github.com/koenno/standard-deviation-service/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func streamedResults() (chan service.StdDevResult, []service.StdDevResult) {
+	results := []service.StdDevResult{
+		{StdDev: 1, Kind: service.Population, Data: []int{1, 3}},
+		{StdDev: 2, Kind: service.Population, Data: []int{2, 6}},
+		{StdDev: 1.8708286933869707, Kind: service.Population, Data: []int{1, 3, 2, 6}, Combined: true},
+	}
+	calcPipe := make(chan service.StdDevResult, len(results))
+	for _, res := range results {
+		calcPipe <- res
+	}
+	close(calcPipe)
+	return calcPipe, results
+}
+
+func TestShouldNegotiateStreamContentType(t *testing.T) {
+	tests := []struct {
+		accept       string
+		expected     string
+		expectStream bool
+	}{
+		{accept: "", expectStream: false},
+		{accept: "application/json", expectStream: false},
+		{accept: "application/x-ndjson", expected: ContentTypeNDJSON, expectStream: true},
+		{accept: "text/event-stream", expected: ContentTypeEventStream, expectStream: true},
+		{accept: "text/html, text/event-stream;q=0.9, application/x-ndjson", expected: ContentTypeNDJSON, expectStream: true},
+		{accept: "text/event-stream, application/x-ndjson", expected: ContentTypeEventStream, expectStream: true},
+		{accept: "*/*", expectStream: false},
+		{accept: "application/json, application/x-ndjson", expectStream: false},
+		{accept: "application/json;q=0.5, application/x-ndjson", expected: ContentTypeNDJSON, expectStream: true},
+		{accept: "application/x-ndjson;q=0.5, */*", expectStream: false},
+		{accept: "application/x-ndjson, */*;q=0.1", expected: ContentTypeNDJSON, expectStream: true},
+		{accept: "application/*;q=0.2, text/event-stream", expected: ContentTypeEventStream, expectStream: true},
+		{accept: "application/json;q=0.9, application/*", expected: ContentTypeNDJSON, expectStream: true},
+		{accept: "application/x-ndjson;q=0", expectStream: false},
+		{accept: "application/x-ndjson;q=high", expectStream: false},
+	}
+	for _, test := range tests {
+		t.Run(test.accept, func(t *testing.T) {
+			// given
+			req := httptest.NewRequest(http.MethodGet, "/random/mean", nil)
+			req.Header.Set("Accept", test.accept)
+
+			// when
+			contentType, ok := streamContentType(req)
+
+			// then
+			assert.Equal(t, test.expectStream, ok)
+			assert.Equal(t, test.expected, contentType)
+		})
+	}
+}
+
+func TestShouldStreamResultsAsNDJSON(t *testing.T) {
+	// given
+	port := 8080
+	req := httptest.NewRequest(http.MethodGet, "/random/mean?requests=2&length=2", nil)
+	req.Header.Set("Accept", ContentTypeNDJSON)
+	w := httptest.NewRecorder()
+	generatorMock := mocks.NewRandomIntegerGenerator(t)
+	calculatorMock := mocks.NewStdDevCalculator(t)
+	sut := NewRandomServer(generatorMock, calculatorMock, port)
+
+	generatorMock.EXPECT().Integers(mock.Anything, 2, defaultMin, defaultMax).Return([]int{1, 3}, nil).Twice()
+	calcPipe, expected := streamedResults()
+	calculatorMock.EXPECT().Calculate(mock.Anything, mock.Anything, service.Population, mock.Anything).Return(calcPipe).Once()
+
+	// when
+	sut.srv.Handler.ServeHTTP(w, req)
+
+	// then
+	res := w.Result()
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, ContentTypeNDJSON, res.Header.Get("Content-Type"))
+	assert.True(t, w.Flushed)
+	lines := strings.Split(strings.TrimSuffix(w.Body.String(), "\n"), "\n")
+	if assert.Len(t, lines, len(expected)) {
+		for i, line := range lines {
+			var actual service.StdDevResult
+			err := json.Unmarshal([]byte(line), &actual)
+			assert.NoError(t, err)
+			expected[i].Combined = false
+			assert.Equal(t, expected[i], actual)
+		}
+	}
+}
+
+func TestShouldStreamResultsAsServerSentEvents(t *testing.T) {
+	// given
+	port := 8080
+	req := httptest.NewRequest(http.MethodGet, "/random/mean?requests=2&length=2", nil)
+	req.Header.Set("Accept", ContentTypeEventStream)
+	w := httptest.NewRecorder()
+	generatorMock := mocks.NewRandomIntegerGenerator(t)
+	calculatorMock := mocks.NewStdDevCalculator(t)
+	sut := NewRandomServer(generatorMock, calculatorMock, port)
+
+	generatorMock.EXPECT().Integers(mock.Anything, 2, defaultMin, defaultMax).Return([]int{1, 3}, nil).Twice()
+	calcPipe, _ := streamedResults()
+	calculatorMock.EXPECT().Calculate(mock.Anything, mock.Anything, service.Population, mock.Anything).Return(calcPipe).Once()
+
+	// when
+	sut.srv.Handler.ServeHTTP(w, req)
+
+	// then
+	res := w.Result()
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, ContentTypeEventStream, res.Header.Get("Content-Type"))
+	assert.Equal(t, "no-cache", res.Header.Get("Cache-Control"))
+	expected := `event: set
+data: {"stddev":1,"kind":"population","data":[1,3]}
+
+event: set
+data: {"stddev":2,"kind":"population","data":[2,6]}
+
+event: combined
+data: {"stddev":1.8708286933869707,"kind":"population","data":[1,3,2,6]}
+
+`
+	assert.Equal(t, expected, w.Body.String())
+}
+
+func TestShouldReportStreamFailureBeforeFirstResultWithStatus(t *testing.T) {
+	// given
+	port := 8080
+	req := httptest.NewRequest(http.MethodGet, "/random/mean?requests=1&length=2", nil)
+	req.Header.Set("Accept", ContentTypeNDJSON)
+	w := httptest.NewRecorder()
+	generatorMock := mocks.NewRandomIntegerGenerator(t)
+	calculatorMock := mocks.NewStdDevCalculator(t)
+	sut := NewRandomServer(generatorMock, calculatorMock, port)
+
+	generatorMock.EXPECT().Integers(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, breaker.ErrOpen).Once()
+	calculatorMock.EXPECT().Calculate(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(make(chan service.StdDevResult)).Once()
+
+	// when
+	sut.srv.Handler.ServeHTTP(w, req)
+
+	// then
+	res := w.Result()
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+	assert.Equal(t, "application/json", res.Header.Get("Content-Type"))
+	var payload ErrorResponse
+	err := json.NewDecoder(res.Body).Decode(&payload)
+	assert.NoError(t, err)
+	assert.Equal(t, CodeCircuitOpen, payload.Code)
+}
+
+func TestShouldReportStreamFailureAfterFirstResultAsErrorEvent(t *testing.T) {
+	// given
+	port := 8080
+	req := httptest.NewRequest(http.MethodGet, "/random/mean?requests=1&length=2", nil)
+	req.Header.Set("Accept", ContentTypeEventStream)
+	w := httptest.NewRecorder()
+	generatorMock := mocks.NewRandomIntegerGenerator(t)
+	calculatorMock := mocks.NewStdDevCalculator(t)
+	sut := NewRandomServer(generatorMock, calculatorMock, port)
+
+	streamed := make(chan struct{})
+	generatorMock.EXPECT().Integers(mock.Anything, 2, defaultMin, defaultMax).RunAndReturn(func(ctx context.Context, quantity, min, max int) ([]int, error) {
+		<-streamed
+		return nil, breaker.ErrOpen
+	}).Once()
+	calcPipe := make(chan service.StdDevResult)
+	calculatorMock.EXPECT().Calculate(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(ctx context.Context, input <-chan []int, kind service.Kind, fields []service.Field) {
+		go func() {
+			calcPipe <- service.StdDevResult{StdDev: 1, Kind: service.Population, Data: []int{1, 3}}
+			close(streamed)
+		}()
+	}).Return(calcPipe).Once()
+
+	// when
+	sut.srv.Handler.ServeHTTP(w, req)
+
+	// then
+	res := w.Result()
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	events := strings.Split(strings.TrimSuffix(w.Body.String(), "\n\n"), "\n\n")
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, `event: set
+data: {"stddev":1,"kind":"population","data":[1,3]}`, events[0])
+		assert.True(t, strings.HasPrefix(events[1], "event: error\ndata: {\"code\":\"circuit_open\""))
+	}
+}
+
+func TestShouldStreamFirstResultBeforeOthersAreGeneratedAndStopWhenClientLeaves(t *testing.T) {
+	// given
+	port := 8080
+	generatorMock := mocks.NewRandomIntegerGenerator(t)
+	sut := NewRandomServer(generatorMock, service.NewStdDevService(), port)
+	URL, runErr := startServer(t, sut)
+	defer func() {
+		assert.NoError(t, sut.Stop(context.Background()))
+		assert.NoError(t, <-runErr)
+	}()
+
+	upstreamErr := make(chan error, 1)
+	generatorMock.EXPECT().Integers(mock.Anything, 2, defaultMin, defaultMax).Return([]int{1, 3}, nil).Once()
+	generatorMock.EXPECT().Integers(mock.Anything, 2, defaultMin, defaultMax).RunAndReturn(func(ctx context.Context, quantity, min, max int) ([]int, error) {
+		<-ctx.Done()
+		upstreamErr <- ctx.Err()
+		return nil, ctx.Err()
+	}).Once()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, URL+"/random/mean?requests=2&length=2", nil)
+	assert.NoError(t, err)
+	req.Header.Set("Accept", ContentTypeNDJSON)
+
+	// when
+	res, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer res.Body.Close()
+	line, err := bufio.NewReader(res.Body).ReadBytes('\n')
+	cancel()
+
+	// then
+	assert.NoError(t, err)
+	var first service.StdDevResult
+	assert.NoError(t, json.Unmarshal(line, &first))
+	assert.Equal(t, []int{1, 3}, first.Data)
+	assert.ErrorIs(t, <-upstreamErr, context.Canceled)
+}
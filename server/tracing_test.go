@@ -0,0 +1,92 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/koenno/standard-deviation-service/server/mocks"
+	"github.com/koenno/standard-deviation-service/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func TestShouldContinueInboundTrace(t *testing.T) {
+	// given
+	recorder := recordSpans(t)
+	port := 8080
+	req := httptest.NewRequest(http.MethodGet, "/random/mean?requests=1&length=2", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	generatorMock := mocks.NewRandomIntegerGenerator(t)
+	calculatorMock := mocks.NewStdDevCalculator(t)
+	sut := NewRandomServer(generatorMock, calculatorMock, port)
+
+	var generatorSpan trace.SpanContext
+	generatorMock.EXPECT().Integers(mock.Anything, 2, defaultMin, defaultMax).Run(func(ctx context.Context, quantity, min, max int) {
+		generatorSpan = trace.SpanContextFromContext(ctx)
+	}).Return([]int{1, 2}, nil).Once()
+	calcPipe := make(chan service.StdDevResult)
+	close(calcPipe)
+	calculatorMock.EXPECT().Calculate(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(calcPipe).Once()
+
+	// when
+	sut.srv.Handler.ServeHTTP(w, req)
+
+	// then
+	spans := recorder.Ended()
+	assert.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "GET /random/mean", span.Name())
+	assert.Equal(t, trace.SpanKindServer, span.SpanKind())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", span.Parent().SpanID().String())
+	assert.True(t, span.Parent().IsRemote())
+	assert.Contains(t, span.Attributes(), attribute.String("http.route", "/random/mean"))
+	assert.Contains(t, span.Attributes(), attribute.Int("http.response.status_code", http.StatusOK))
+	assert.Equal(t, span.SpanContext().TraceID(), generatorSpan.TraceID())
+}
+
+func TestShouldMarkSpanOfFailedRequest(t *testing.T) {
+	// given
+	recorder := recordSpans(t)
+	port := 8080
+	req := httptest.NewRequest(http.MethodGet, "/random/mean?requests=1&length=2", nil)
+	w := httptest.NewRecorder()
+	generatorMock := mocks.NewRandomIntegerGenerator(t)
+	calculatorMock := mocks.NewStdDevCalculator(t)
+	sut := NewRandomServer(generatorMock, calculatorMock, port)
+
+	generatorMock.EXPECT().Integers(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("failure")).Once()
+	calcPipe := make(chan service.StdDevResult)
+	close(calcPipe)
+	calculatorMock.EXPECT().Calculate(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(calcPipe).Once()
+
+	// when
+	sut.srv.Handler.ServeHTTP(w, req)
+
+	// then
+	spans := recorder.Ended()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.False(t, spans[0].Parent().IsValid())
+	if assert.Len(t, spans[0].Events(), 1) {
+		assert.Equal(t, "exception", spans[0].Events()[0].Name)
+	}
+}
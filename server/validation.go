@@ -4,43 +4,252 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
+
+	"github.com/koenno/standard-deviation-service/service"
+)
+
+const (
+	defaultMin    = 1
+	defaultMax    = 10
+	defaultSource = "random.org"
+
+	// random.org accepts integers within [-1e9, 1e9]
+	lowerBound = -1_000_000_000
+	upperBound = 1_000_000_000
 )
 
 var (
 	ErrParamNotInteger         = errors.New("parameter must be an integer")
 	ErrParamNotPositiveInteger = errors.New("parameter must be a positive integer")
+	ErrParamOutOfBounds        = fmt.Errorf("parameter must be within [%d, %d]", lowerBound, upperBound)
+	ErrParamMinNotLessThanMax  = errors.New("parameter must be less than max")
+	ErrParamUnknownKind        = fmt.Errorf("parameter must be either %s or %s", service.Population, service.Sample)
+	ErrParamUnknownField       = fmt.Errorf("parameter must be a comma-separated list of: %s", fieldNames())
+	ErrParamTooLarge           = errors.New("parameter must not exceed")
+	ErrParamTotalTooLarge      = errors.New("parameter multiplied by requests must not exceed")
+	ErrParamUnknownSource      = errors.New("parameter must be one of:")
 )
 
-//go:generate mockery --name=Handler --srcpkg net/http --case underscore --with-expecter
+type validator struct {
+	limits        Limits
+	sources       []string
+	defaultSource string
+	defaultMin    int
+	defaultMax    int
+}
+
+type meanParams struct {
+	requests int
+	length   int
+	min      int
+	max      int
+	kind     service.Kind
+	fields   []service.Field
+	source   string
+	// sets lists every set individually and overrides the uniform shape above.
+	sets []setParams
+	// combinedData keeps the integers of the combined result even when
+	// fields do not ask for them.
+	combinedData bool
+	// concurrency is the number of sets generated at once, 0 generates every
+	// set at once.
+	concurrency int
+}
+
+type setParams struct {
+	length int
+	min    int
+	max    int
+	source string
+}
 
-func validationMiddleware(next http.Handler) http.Handler {
-	f := func(w http.ResponseWriter, r *http.Request) {
-		_, err := paramPositiveInt(r, "requests")
-		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			w.Write([]byte(err.Error()))
-			return
+// calculationFields returns the fields to calculate, asking for the data of
+// the combined result when it is always returned.
+func (p meanParams) calculationFields() []service.Field {
+	if !p.combinedData || slices.Contains(p.fields, service.FieldData) {
+		return p.fields
+	}
+	return append(slices.Clip(p.fields), service.FieldData)
+}
+
+// setList returns the sets to generate.
+func (p meanParams) setList() []setParams {
+	if p.sets != nil {
+		return p.sets
+	}
+	sets := make([]setParams, p.requests)
+	for i := range sets {
+		sets[i] = setParams{
+			length: p.length,
+			min:    p.min,
+			max:    p.max,
+			source: p.source,
 		}
-		_, err = paramPositiveInt(r, "length")
-		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			w.Write([]byte(err.Error()))
-			return
+	}
+	return sets
+}
+
+//go:generate mockery --name=Handler --srcpkg net/http --case underscore --with-expecter
+
+func validationMiddleware(v validator) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		f := func(w http.ResponseWriter, r *http.Request) {
+			_, err := v.parseMeanParams(r)
+			if err != nil {
+				writeError(w, r, err)
+				return
+			}
+			next.ServeHTTP(w, r)
 		}
-		next.ServeHTTP(w, r)
+		return http.HandlerFunc(f)
+	}
+}
+
+func (v validator) parseMeanParams(r *http.Request) (meanParams, error) {
+	requests, err := paramPositiveInt(r, "requests")
+	if err != nil {
+		return meanParams{}, err
+	}
+	length, err := paramPositiveInt(r, "length")
+	if err != nil {
+		return meanParams{}, err
+	}
+	err = checkLimits(requests, length, v.limits)
+	if err != nil {
+		return meanParams{}, err
+	}
+	min, max, err := v.paramRange(r)
+	if err != nil {
+		return meanParams{}, err
+	}
+	kind, err := paramKind(r)
+	if err != nil {
+		return meanParams{}, err
+	}
+	fields, err := paramFields(r)
+	if err != nil {
+		return meanParams{}, err
+	}
+	source, err := v.paramSource(r)
+	if err != nil {
+		return meanParams{}, err
 	}
-	return http.HandlerFunc(f)
+	return meanParams{
+		concurrency: v.limits.MaxConcurrency,
+		requests:    requests,
+		length:      length,
+		min:         min,
+		max:         max,
+		kind:        kind,
+		fields:      fields,
+		source:      source,
+		// GET responses have always carried the combined integers
+		combinedData: true,
+	}, nil
 }
 
 func paramPositiveInt(r *http.Request, param string) (int, error) {
 	requestsStr := r.URL.Query().Get(param)
 	value, err := strconv.Atoi(requestsStr)
 	if err != nil {
-		return 0, fmt.Errorf("%s %w", param, ErrParamNotInteger)
+		return 0, &ParamError{Param: param, Err: ErrParamNotInteger}
 	}
 	if value <= 0 {
-		return 0, fmt.Errorf("%s %w", param, ErrParamNotPositiveInteger)
+		return 0, &ParamError{Param: param, Err: ErrParamNotPositiveInteger}
 	}
 	return value, nil
 }
+
+func checkLimits(requests, length int, limits Limits) error {
+	if limits.MaxRequests > 0 && requests > limits.MaxRequests {
+		return &ParamError{Param: "requests", Err: fmt.Errorf("%w %d", ErrParamTooLarge, limits.MaxRequests)}
+	}
+	if limits.MaxLength > 0 && length > limits.MaxLength {
+		return &ParamError{Param: "length", Err: fmt.Errorf("%w %d", ErrParamTooLarge, limits.MaxLength)}
+	}
+	// requests*length may overflow, requests is positive
+	if limits.MaxTotal > 0 && length > limits.MaxTotal/requests {
+		return &ParamError{Param: "length", Err: fmt.Errorf("%w %d", ErrParamTotalTooLarge, limits.MaxTotal)}
+	}
+	return nil
+}
+
+func (v validator) paramRange(r *http.Request) (int, int, error) {
+	min, err := paramBoundedInt(r, "min", v.defaultMin)
+	if err != nil {
+		return 0, 0, err
+	}
+	max, err := paramBoundedInt(r, "max", v.defaultMax)
+	if err != nil {
+		return 0, 0, err
+	}
+	if min >= max {
+		return 0, 0, &ParamError{Param: "min", Err: ErrParamMinNotLessThanMax}
+	}
+	return min, max, nil
+}
+
+func paramBoundedInt(r *http.Request, param string, defaultValue int) (int, error) {
+	valueStr := r.URL.Query().Get(param)
+	if valueStr == "" {
+		return defaultValue, nil
+	}
+	value, err := strconv.Atoi(valueStr)
+	if err != nil {
+		return 0, &ParamError{Param: param, Err: ErrParamNotInteger}
+	}
+	if value < lowerBound || value > upperBound {
+		return 0, &ParamError{Param: param, Err: ErrParamOutOfBounds}
+	}
+	return value, nil
+}
+
+func paramKind(r *http.Request) (service.Kind, error) {
+	kind := service.Kind(r.URL.Query().Get("kind"))
+	switch kind {
+	case "":
+		return service.Population, nil
+	case service.Population, service.Sample:
+		return kind, nil
+	default:
+		return "", &ParamError{Param: "kind", Err: ErrParamUnknownKind}
+	}
+}
+
+func paramFields(r *http.Request) ([]service.Field, error) {
+	fieldsStr := r.URL.Query().Get("fields")
+	if fieldsStr == "" {
+		return nil, nil
+	}
+	var fields []service.Field
+	for _, name := range strings.Split(fieldsStr, ",") {
+		field := service.Field(strings.TrimSpace(name))
+		if !service.ValidField(field) {
+			return nil, &ParamError{Param: "fields", Err: ErrParamUnknownField}
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+func fieldNames() string {
+	names := make([]string, len(service.Fields))
+	for i, field := range service.Fields {
+		names[i] = string(field)
+	}
+	return strings.Join(names, ", ")
+}
+
+func (v validator) paramSource(r *http.Request) (string, error) {
+	source := r.URL.Query().Get("source")
+	if source == "" {
+		return v.defaultSource, nil
+	}
+	if !slices.Contains(v.sources, source) {
+		return "", &ParamError{Param: "source", Err: fmt.Errorf("%w %s", ErrParamUnknownSource, strings.Join(v.sources, ", "))}
+	}
+	return source, nil
+}
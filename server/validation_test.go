@@ -1,8 +1,8 @@
 package server
 
 import (
+	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -17,66 +17,77 @@ func TestShouldReturnBadRequestWhenRequiredQueryParamsAreNotValid(t *testing.T)
 		requests        string
 		length          string
 		expectedPayload string
+		expectedParam   string
 	}{
 		{
 			name:            "missing requests",
 			requests:        "",
 			length:          "1",
 			expectedPayload: "requests parameter must be an integer",
+			expectedParam:   "requests",
 		},
 		{
 			name:            "negative requests",
 			requests:        "-1",
 			length:          "1",
 			expectedPayload: "requests parameter must be a positive integer",
+			expectedParam:   "requests",
 		},
 		{
 			name:            "zero requests",
 			requests:        "0",
 			length:          "1",
 			expectedPayload: "requests parameter must be a positive integer",
+			expectedParam:   "requests",
 		},
 		{
 			name:            "missing length",
 			requests:        "1",
 			length:          "",
 			expectedPayload: "length parameter must be an integer",
+			expectedParam:   "length",
 		},
 		{
 			name:            "negative length",
 			requests:        "1",
 			length:          "-1",
 			expectedPayload: "length parameter must be a positive integer",
+			expectedParam:   "length",
 		},
 		{
 			name:            "zero length",
 			requests:        "1",
 			length:          "0",
 			expectedPayload: "length parameter must be a positive integer",
+			expectedParam:   "length",
 		},
 		{
 			name:            "missing both",
 			requests:        "",
 			length:          "",
 			expectedPayload: "requests parameter must be an integer",
+			expectedParam:   "requests",
 		},
 		{
 			name:            "no number in requests",
 			requests:        "A",
 			length:          "1",
 			expectedPayload: "requests parameter must be an integer",
+			expectedParam:   "requests",
 		},
 		{
 			name:            "no number in length",
 			requests:        "1",
 			length:          "A",
 			expectedPayload: "length parameter must be an integer",
+			expectedParam:   "length",
 		},
 		{
 			name:            "no number in both",
 			requests:        "A",
 			length:          "B",
 			expectedPayload: "requests parameter must be an integer",
+			expectedParam:   "requests",
 		},
 	}
 	for _, test := range tests {
@@ -86,7 +97,7 @@ func TestShouldReturnBadRequestWhenRequiredQueryParamsAreNotValid(t *testing.T)
 			req := httptest.NewRequest(http.MethodGet, URL, nil)
 			w := httptest.NewRecorder()
 			httpHandlerMock := mocks.NewHandler(t)
-			sut := validationMiddleware(httpHandlerMock)
+			sut := validationMiddleware(newTestValidator(DefaultLimits()))(httpHandlerMock)
 
 			// when
 			sut.ServeHTTP(w, req)
@@ -94,10 +105,315 @@ func TestShouldReturnBadRequestWhenRequiredQueryParamsAreNotValid(t *testing.T)
 			// then
 			res := w.Result()
 			defer res.Body.Close()
-			data, err := io.ReadAll(res.Body)
+			var payload ErrorResponse
+			err := json.NewDecoder(res.Body).Decode(&payload)
 			assert.NoError(t, err)
-			assert.Equal(t, test.expectedPayload, string(data))
+			assert.Equal(t, test.expectedPayload, payload.Message)
+			assert.Equal(t, test.expectedParam, payload.Parameter)
+			assert.Equal(t, CodeInvalidParameter, payload.Code)
 			assert.Equal(t, http.StatusBadRequest, res.StatusCode)
 		})
 	}
 }
+
+func TestShouldReturnBadRequestWhenRangeQueryParamsAreNotValid(t *testing.T) {
+	tests := []struct {
+		name            string
+		min             string
+		max             string
+		expectedPayload string
+		expectedParam   string
+	}{
+		{
+			name:            "no number in min",
+			min:             "A",
+			max:             "10",
+			expectedPayload: "min parameter must be an integer",
+			expectedParam:   "min",
+		},
+		{
+			name:            "no number in max",
+			min:             "1",
+			max:             "B",
+			expectedPayload: "max parameter must be an integer",
+			expectedParam:   "max",
+		},
+		{
+			name:            "min below lower bound",
+			min:             "-1000000001",
+			max:             "10",
+			expectedPayload: "min parameter must be within [-1000000000, 1000000000]",
+			expectedParam:   "min",
+		},
+		{
+			name:            "max above upper bound",
+			min:             "1",
+			max:             "1000000001",
+			expectedPayload: "max parameter must be within [-1000000000, 1000000000]",
+			expectedParam:   "max",
+		},
+		{
+			name:            "min equal to max",
+			min:             "5",
+			max:             "5",
+			expectedPayload: "min parameter must be less than max",
+			expectedParam:   "min",
+		},
+		{
+			name:            "min greater than max",
+			min:             "6",
+			max:             "5",
+			expectedPayload: "min parameter must be less than max",
+			expectedParam:   "min",
+		},
+		{
+			name:            "min greater than default max",
+			min:             "11",
+			max:             "",
+			expectedPayload: "min parameter must be less than max",
+			expectedParam:   "min",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// given
+			URL := fmt.Sprintf("/random/mean?requests=1&length=1&min=%s&max=%s", test.min, test.max)
+			req := httptest.NewRequest(http.MethodGet, URL, nil)
+			w := httptest.NewRecorder()
+			httpHandlerMock := mocks.NewHandler(t)
+			sut := validationMiddleware(newTestValidator(DefaultLimits()))(httpHandlerMock)
+
+			// when
+			sut.ServeHTTP(w, req)
+
+			// then
+			res := w.Result()
+			defer res.Body.Close()
+			var payload ErrorResponse
+			err := json.NewDecoder(res.Body).Decode(&payload)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedPayload, payload.Message)
+			assert.Equal(t, test.expectedParam, payload.Parameter)
+			assert.Equal(t, CodeInvalidParameter, payload.Code)
+			assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		})
+	}
+}
+
+func TestShouldPassRequestWhenRangeQueryParamsAreValid(t *testing.T) {
+	tests := []struct {
+		name string
+		min  string
+		max  string
+	}{
+		{
+			name: "default range",
+			min:  "",
+			max:  "",
+		},
+		{
+			name: "negative range",
+			min:  "-100",
+			max:  "-1",
+		},
+		{
+			name: "full range",
+			min:  "-1000000000",
+			max:  "1000000000",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// given
+			URL := fmt.Sprintf("/random/mean?requests=1&length=1&min=%s&max=%s", test.min, test.max)
+			req := httptest.NewRequest(http.MethodGet, URL, nil)
+			w := httptest.NewRecorder()
+			httpHandlerMock := mocks.NewHandler(t)
+			sut := validationMiddleware(newTestValidator(DefaultLimits()))(httpHandlerMock)
+
+			httpHandlerMock.EXPECT().ServeHTTP(w, req).Once()
+
+			// when
+			sut.ServeHTTP(w, req)
+
+			// then
+			assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+		})
+	}
+}
+
+func TestShouldReturnBadRequestWhenKindQueryParamIsNotValid(t *testing.T) {
+	// given
+	URL := "/random/mean?requests=1&length=1&kind=median"
+	req := httptest.NewRequest(http.MethodGet, URL, nil)
+	w := httptest.NewRecorder()
+	httpHandlerMock := mocks.NewHandler(t)
+	sut := validationMiddleware(newTestValidator(DefaultLimits()))(httpHandlerMock)
+
+	// when
+	sut.ServeHTTP(w, req)
+
+	// then
+	res := w.Result()
+	defer res.Body.Close()
+	var payload ErrorResponse
+	err := json.NewDecoder(res.Body).Decode(&payload)
+	assert.NoError(t, err)
+	assert.Equal(t, "kind parameter must be either population or sample", payload.Message)
+	assert.Equal(t, "kind", payload.Parameter)
+	assert.Equal(t, CodeInvalidParameter, payload.Code)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+}
+
+func TestShouldReturnBadRequestWhenFieldsQueryParamIsNotValid(t *testing.T) {
+	// given
+	URL := "/random/mean?requests=1&length=1&fields=mean,mode"
+	req := httptest.NewRequest(http.MethodGet, URL, nil)
+	w := httptest.NewRecorder()
+	httpHandlerMock := mocks.NewHandler(t)
+	sut := validationMiddleware(newTestValidator(DefaultLimits()))(httpHandlerMock)
+
+	// when
+	sut.ServeHTTP(w, req)
+
+	// then
+	res := w.Result()
+	defer res.Body.Close()
+	var payload ErrorResponse
+	err := json.NewDecoder(res.Body).Decode(&payload)
+	assert.NoError(t, err)
+	assert.Equal(t, "fields parameter must be a comma-separated list of: mean, median, min, max, variance, range, count, sum, data", payload.Message)
+	assert.Equal(t, "fields", payload.Parameter)
+	assert.Equal(t, CodeInvalidParameter, payload.Code)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+}
+
+func TestShouldReturnUnprocessableEntityWhenLimitsAreExceeded(t *testing.T) {
+	tests := []struct {
+		name            string
+		requests        string
+		length          string
+		expectedPayload string
+		expectedParam   string
+	}{
+		{
+			name:            "too many requests",
+			requests:        "11",
+			length:          "1",
+			expectedPayload: "requests parameter must not exceed 10",
+			expectedParam:   "requests",
+		},
+		{
+			name:            "too long length",
+			requests:        "1",
+			length:          "101",
+			expectedPayload: "length parameter must not exceed 100",
+			expectedParam:   "length",
+		},
+		{
+			name:            "too many numbers in total",
+			requests:        "10",
+			length:          "51",
+			expectedPayload: "length parameter multiplied by requests must not exceed 500",
+			expectedParam:   "length",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// given
+			limits := Limits{MaxRequests: 10, MaxLength: 100, MaxTotal: 500}
+			URL := fmt.Sprintf("/random/mean?requests=%s&length=%s", test.requests, test.length)
+			req := httptest.NewRequest(http.MethodGet, URL, nil)
+			w := httptest.NewRecorder()
+			httpHandlerMock := mocks.NewHandler(t)
+			sut := validationMiddleware(newTestValidator(limits))(httpHandlerMock)
+
+			// when
+			sut.ServeHTTP(w, req)
+
+			// then
+			res := w.Result()
+			defer res.Body.Close()
+			var payload ErrorResponse
+			err := json.NewDecoder(res.Body).Decode(&payload)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedPayload, payload.Message)
+			assert.Equal(t, test.expectedParam, payload.Parameter)
+			assert.Equal(t, CodeLimitExceeded, payload.Code)
+			assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		})
+	}
+}
+
+func TestShouldRejectTotalOverflowingInt(t *testing.T) {
+	// given
+	URL := "/random/mean?requests=4294967296&length=4294967296"
+	req := httptest.NewRequest(http.MethodGet, URL, nil)
+	w := httptest.NewRecorder()
+	httpHandlerMock := mocks.NewHandler(t)
+	sut := validationMiddleware(newTestValidator(Limits{MaxTotal: 500}))(httpHandlerMock)
+
+	// when
+	sut.ServeHTTP(w, req)
+
+	// then
+	res := w.Result()
+	defer res.Body.Close()
+	var payload ErrorResponse
+	err := json.NewDecoder(res.Body).Decode(&payload)
+	assert.NoError(t, err)
+	assert.Equal(t, "length parameter multiplied by requests must not exceed 500", payload.Message)
+	assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+}
+
+func TestShouldPassRequestWhenLimitsAreDisabled(t *testing.T) {
+	// given
+	URL := "/random/mean?requests=1000&length=100000"
+	req := httptest.NewRequest(http.MethodGet, URL, nil)
+	w := httptest.NewRecorder()
+	httpHandlerMock := mocks.NewHandler(t)
+	sut := validationMiddleware(newTestValidator(Limits{}))(httpHandlerMock)
+
+	httpHandlerMock.EXPECT().ServeHTTP(w, req).Once()
+
+	// when
+	sut.ServeHTTP(w, req)
+
+	// then
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+}
+
+func TestShouldReturnBadRequestWhenSourceQueryParamIsNotValid(t *testing.T) {
+	// given
+	URL := "/random/mean?requests=1&length=1&source=dice"
+	req := httptest.NewRequest(http.MethodGet, URL, nil)
+	w := httptest.NewRecorder()
+	httpHandlerMock := mocks.NewHandler(t)
+	v := newTestValidator(DefaultLimits())
+	v.sources = []string{"crypto", "local", "random.org"}
+	sut := validationMiddleware(v)(httpHandlerMock)
+
+	// when
+	sut.ServeHTTP(w, req)
+
+	// then
+	res := w.Result()
+	defer res.Body.Close()
+	var payload ErrorResponse
+	err := json.NewDecoder(res.Body).Decode(&payload)
+	assert.NoError(t, err)
+	assert.Equal(t, "source parameter must be one of: crypto, local, random.org", payload.Message)
+	assert.Equal(t, "source", payload.Parameter)
+	assert.Equal(t, CodeInvalidParameter, payload.Code)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+}
+
+func newTestValidator(limits Limits) validator {
+	return validator{
+		limits:        limits,
+		sources:       []string{defaultSource},
+		defaultSource: defaultSource,
+		defaultMin:    defaultMin,
+		defaultMax:    defaultMax,
+	}
+}
@@ -1,9 +1,52 @@
 package service
 
 import (
+	"context"
+	"slices"
+
 	"github.com/koenno/standard-deviation-service/stats"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/koenno/standard-deviation-service/service"
+
+type Kind string
+
+const (
+	Population Kind = "population"
+	Sample     Kind = "sample"
+)
+
+type Field string
+
+const (
+	FieldMean     Field = "mean"
+	FieldMedian   Field = "median"
+	FieldMin      Field = "min"
+	FieldMax      Field = "max"
+	FieldVariance Field = "variance"
+	FieldRange    Field = "range"
+	FieldCount    Field = "count"
+	FieldSum      Field = "sum"
+	// FieldData keeps the integers of the combined result, which otherwise
+	// carries the statistics only.
+	FieldData Field = "data"
 )
 
+var Fields = []Field{
+	FieldMean,
+	FieldMedian,
+	FieldMin,
+	FieldMax,
+	FieldVariance,
+	FieldRange,
+	FieldCount,
+	FieldSum,
+	FieldData,
+}
+
 type StdDevService struct {
 }
 
@@ -12,31 +55,139 @@ func NewStdDevService() StdDevService {
 }
 
 type StdDevResult struct {
-	StdDev float64 `json:"stddev"`
-	Data   []int   `json:"data"`
+	StdDev   float64  `json:"stddev"`
+	Kind     Kind     `json:"kind"`
+	Mean     *float64 `json:"mean,omitempty"`
+	Median   *float64 `json:"median,omitempty"`
+	Min      *int     `json:"min,omitempty"`
+	Max      *int     `json:"max,omitempty"`
+	Variance *float64 `json:"variance,omitempty"`
+	Range    *int     `json:"range,omitempty"`
+	Count    *int     `json:"count,omitempty"`
+	Sum      *int     `json:"sum,omitempty"`
+	Data     []int    `json:"data,omitempty"`
+	// Combined marks the result calculated over all sets together.
+	Combined bool `json:"-"`
 }
 
-func (s StdDevService) Calculate(input <-chan []int) <-chan StdDevResult {
+// Calculate describes every set received from input as soon as it arrives and,
+// once input is closed, all sets together. The sets are only concatenated for
+// the combined result when FieldData or FieldMedian is requested; every other
+// statistic is merged set by set. The span lasts until output is closed.
+func (s StdDevService) Calculate(ctx context.Context, input <-chan []int, kind Kind, fields []Field) <-chan StdDevResult {
+	_, span := otel.Tracer(tracerName).Start(ctx, "service.Calculate", trace.WithAttributes(attribute.String("stddev.kind", string(kind))))
 	output := make(chan StdDevResult)
+	keepData := slices.Contains(fields, FieldData) || slices.Contains(fields, FieldMedian)
 	go func() {
+		defer span.End()
 		defer close(output)
-		var setSum []int
+		var total summary
+		sets := 0
 		for set := range input {
-			setSum = append(setSum, set...)
-			stddev := stats.StandardDeviation(set...)
-			output <- StdDevResult{
-				StdDev: stddev,
-				Data:   set,
-			}
+			sets++
+			current := summarize(set)
+			total.merge(current, keepData)
+			output <- describe(current, kind, fields)
 		}
-		if len(setSum) == 0 {
+		span.SetAttributes(attribute.Int("stddev.sets", sets), attribute.Int("stddev.count", total.acc.Count()))
+		if total.acc.Count() == 0 {
 			return
 		}
-		stddev := stats.StandardDeviation(setSum...)
-		output <- StdDevResult{
-			StdDev: stddev,
-			Data:   setSum,
+		combined := describe(total, kind, fields)
+		combined.Combined = true
+		if !slices.Contains(fields, FieldData) {
+			// kept for the median only
+			combined.Data = nil
 		}
+		output <- combined
 	}()
 	return output
 }
+
+// summary holds the statistics of one or more sets that can be merged without
+// their data.
+type summary struct {
+	acc  stats.Accumulator
+	min  int
+	max  int
+	sum  int
+	data []int
+}
+
+func summarize(set []int) summary {
+	return summary{
+		acc:  stats.Accumulate(set...),
+		min:  stats.Min(set...),
+		max:  stats.Max(set...),
+		sum:  stats.Sum(set...),
+		data: set,
+	}
+}
+
+func (s *summary) merge(other summary, keepData bool) {
+	if other.acc.Count() == 0 {
+		return
+	}
+	if s.acc.Count() == 0 || other.min < s.min {
+		s.min = other.min
+	}
+	if s.acc.Count() == 0 || other.max > s.max {
+		s.max = other.max
+	}
+	s.acc.Merge(other.acc)
+	s.sum += other.sum
+	if keepData {
+		s.data = append(s.data, other.data...)
+	}
+}
+
+func describe(st summary, kind Kind, fields []Field) StdDevResult {
+	res := StdDevResult{
+		StdDev: standardDeviation(kind, st.acc),
+		Kind:   kind,
+		Data:   st.data,
+	}
+	for _, field := range fields {
+		switch field {
+		case FieldMean:
+			res.Mean = ptr(st.acc.Mean())
+		case FieldMedian:
+			res.Median = ptr(stats.Median(st.data...))
+		case FieldMin:
+			res.Min = ptr(st.min)
+		case FieldMax:
+			res.Max = ptr(st.max)
+		case FieldVariance:
+			res.Variance = ptr(variance(kind, st.acc))
+		case FieldRange:
+			res.Range = ptr(st.max - st.min)
+		case FieldCount:
+			res.Count = ptr(st.acc.Count())
+		case FieldSum:
+			res.Sum = ptr(st.sum)
+		}
+	}
+	return res
+}
+
+func ValidField(field Field) bool {
+	return slices.Contains(Fields, field)
+}
+
+func standardDeviation(kind Kind, acc stats.Accumulator) float64 {
+	if kind == Sample {
+		return acc.SampleStdDev()
+	}
+	return acc.StdDev()
+}
+
+func variance(kind Kind, acc stats.Accumulator) float64 {
+	if kind == Sample {
+		return acc.SampleVariance()
+	}
+	return acc.Variance()
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -1,9 +1,15 @@
 package service
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 func TestShouldReturnNothingWhenEmptyInputIsGiven(t *testing.T) {
@@ -13,7 +19,7 @@ func TestShouldReturnNothingWhenEmptyInputIsGiven(t *testing.T) {
 	close(pipe)
 
 	// when
-	resultPipe := sut.Calculate(pipe)
+	resultPipe := sut.Calculate(context.Background(), pipe, Population, nil)
 
 	// then
 	results := read(resultPipe)
@@ -34,11 +40,14 @@ func TestShouldReturnStandardDeviationResult(t *testing.T) {
 			expected: []StdDevResult{
 				{
 					StdDev: 0,
+					Kind:   Population,
 					Data:   []int{3},
 				},
 				{
-					StdDev: 0,
-					Data:   []int{3},
+					StdDev:   0,
+					Kind:     Population,
+					Data:     []int{3},
+					Combined: true,
 				},
 			},
 		},
@@ -51,15 +60,19 @@ func TestShouldReturnStandardDeviationResult(t *testing.T) {
 			expected: []StdDevResult{
 				{
 					StdDev: 1.4142135623730951,
+					Kind:   Population,
 					Data:   []int{1, 2, 3, 4, 5},
 				},
 				{
 					StdDev: 1.118033988749895,
+					Kind:   Population,
 					Data:   []int{6, 7, 8, 9},
 				},
 				{
-					StdDev: 2.581988897471611,
-					Data:   []int{1, 2, 3, 4, 5, 6, 7, 8, 9},
+					StdDev:   2.581988897471611,
+					Kind:     Population,
+					Data:     []int{1, 2, 3, 4, 5, 6, 7, 8, 9},
+					Combined: true,
 				},
 			},
 		},
@@ -77,7 +90,7 @@ func TestShouldReturnStandardDeviationResult(t *testing.T) {
 			}()
 
 			// when
-			resultPipe := sut.Calculate(pipe)
+			resultPipe := sut.Calculate(context.Background(), pipe, Population, []Field{FieldData})
 
 			// then
 			results := read(resultPipe)
@@ -86,6 +99,135 @@ func TestShouldReturnStandardDeviationResult(t *testing.T) {
 	}
 }
 
+func TestShouldReturnSampleStandardDeviationResult(t *testing.T) {
+	// given
+	sut := NewStdDevService()
+	pipe := make(chan []int)
+	go func() {
+		defer close(pipe)
+		pipe <- []int{1, 2, 3, 4, 5}
+		pipe <- []int{6, 7, 8, 9}
+	}()
+	expected := []StdDevResult{
+		{
+			StdDev: 1.5811388300841898,
+			Kind:   Sample,
+			Data:   []int{1, 2, 3, 4, 5},
+		},
+		{
+			StdDev: 1.2909944487358056,
+			Kind:   Sample,
+			Data:   []int{6, 7, 8, 9},
+		},
+		{
+			StdDev:   2.7386127875258306,
+			Kind:     Sample,
+			Data:     []int{1, 2, 3, 4, 5, 6, 7, 8, 9},
+			Combined: true,
+		},
+	}
+
+	// when
+	resultPipe := sut.Calculate(context.Background(), pipe, Sample, []Field{FieldData})
+
+	// then
+	results := read(resultPipe)
+	assert.ElementsMatch(t, expected, results)
+}
+
+func TestShouldReturnRequestedFields(t *testing.T) {
+	// given
+	sut := NewStdDevService()
+	pipe := make(chan []int)
+	go func() {
+		defer close(pipe)
+		pipe <- []int{5, 1, 3}
+		pipe <- []int{4, 2}
+	}()
+	expected := []StdDevResult{
+		{
+			StdDev:   1.632993161855452,
+			Kind:     Population,
+			Mean:     ptr(3.0),
+			Median:   ptr(3.0),
+			Min:      ptr(1),
+			Max:      ptr(5),
+			Variance: ptr(2.6666666666666665),
+			Range:    ptr(4),
+			Count:    ptr(3),
+			Sum:      ptr(9),
+			Data:     []int{5, 1, 3},
+		},
+		{
+			StdDev:   1,
+			Kind:     Population,
+			Mean:     ptr(3.0),
+			Median:   ptr(3.0),
+			Min:      ptr(2),
+			Max:      ptr(4),
+			Variance: ptr(1.0),
+			Range:    ptr(2),
+			Count:    ptr(2),
+			Sum:      ptr(6),
+			Data:     []int{4, 2},
+		},
+		{
+			StdDev:   1.4142135623730951,
+			Kind:     Population,
+			Mean:     ptr(3.0),
+			Median:   ptr(3.0),
+			Min:      ptr(1),
+			Max:      ptr(5),
+			Variance: ptr(2.0),
+			Range:    ptr(4),
+			Count:    ptr(5),
+			Sum:      ptr(15),
+			Data:     []int{5, 1, 3, 4, 2},
+			Combined: true,
+		},
+	}
+
+	// when
+	resultPipe := sut.Calculate(context.Background(), pipe, Population, Fields)
+
+	// then
+	results := read(resultPipe)
+	assert.ElementsMatch(t, expected, results)
+}
+
+func TestShouldMergeCombinedStatisticsWithoutData(t *testing.T) {
+	// given
+	sut := NewStdDevService()
+	pipe := make(chan []int, 2)
+	pipe <- []int{5, 1, 3}
+	pipe <- []int{4, 2}
+	close(pipe)
+	fields := []Field{FieldMean, FieldMedian, FieldMin, FieldMax, FieldVariance, FieldRange, FieldCount, FieldSum}
+	expected := StdDevResult{
+		StdDev:   1.4142135623730951,
+		Kind:     Population,
+		Mean:     ptr(3.0),
+		Median:   ptr(3.0),
+		Min:      ptr(1),
+		Max:      ptr(5),
+		Variance: ptr(2.0),
+		Range:    ptr(4),
+		Count:    ptr(5),
+		Sum:      ptr(15),
+		Combined: true,
+	}
+
+	// when
+	resultPipe := sut.Calculate(context.Background(), pipe, Population, fields)
+
+	// then
+	results := read(resultPipe)
+	if assert.Len(t, results, 3) {
+		assert.Equal(t, expected, results[2])
+		assert.Equal(t, []int{5, 1, 3}, results[0].Data)
+	}
+}
+
 func read(pipe <-chan StdDevResult) []StdDevResult {
 	var res []StdDevResult
 	for r := range pipe {
@@ -93,3 +235,30 @@ func read(pipe <-chan StdDevResult) []StdDevResult {
 	}
 	return res
 }
+
+func TestShouldTraceCalculationUntilOutputIsClosed(t *testing.T) {
+	// given
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(previous)
+	sut := NewStdDevService()
+	pipe := make(chan []int, 2)
+	pipe <- []int{1, 2, 3}
+	pipe <- []int{4, 5}
+	close(pipe)
+
+	// when
+	resultPipe := sut.Calculate(context.Background(), pipe, Sample, nil)
+	assert.Empty(t, recorder.Ended())
+	for range resultPipe {
+	}
+
+	// then
+	assert.Eventually(t, func() bool { return len(recorder.Ended()) == 1 }, time.Second, time.Millisecond)
+	span := recorder.Ended()[0]
+	assert.Equal(t, "service.Calculate", span.Name())
+	assert.Contains(t, span.Attributes(), attribute.String("stddev.kind", "sample"))
+	assert.Contains(t, span.Attributes(), attribute.Int("stddev.sets", 2))
+	assert.Contains(t, span.Attributes(), attribute.Int("stddev.count", 5))
+}
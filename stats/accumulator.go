@@ -0,0 +1,78 @@
+package stats
+
+import "math"
+
+// Accumulator computes running statistics in a single pass using Welford's
+// algorithm. Accumulators of disjoint data sets can be merged, so statistics
+// of a union never require the union itself.
+type Accumulator struct {
+	count int
+	mean  float64
+	m2    float64
+}
+
+func Accumulate[T Numbers](numbers ...T) Accumulator {
+	var acc Accumulator
+	for _, number := range numbers {
+		acc.Add(float64(number))
+	}
+	return acc
+}
+
+func (a *Accumulator) Add(value float64) {
+	a.count++
+	delta := value - a.mean
+	a.mean += delta / float64(a.count)
+	a.m2 += delta * (value - a.mean)
+}
+
+// Merge combines other into a using the parallel variant of the algorithm
+// by Chan et al.
+func (a *Accumulator) Merge(other Accumulator) {
+	if other.count == 0 {
+		return
+	}
+	if a.count == 0 {
+		*a = other
+		return
+	}
+	count := a.count + other.count
+	delta := other.mean - a.mean
+	a.mean += delta * float64(other.count) / float64(count)
+	a.m2 += other.m2 + delta*delta*float64(a.count)*float64(other.count)/float64(count)
+	a.count = count
+}
+
+func (a Accumulator) Count() int {
+	return a.count
+}
+
+func (a Accumulator) Mean() float64 {
+	return a.mean
+}
+
+// Variance returns the population variance.
+func (a Accumulator) Variance() float64 {
+	if a.count == 0 {
+		return 0.0
+	}
+	return a.m2 / float64(a.count)
+}
+
+// SampleVariance returns the variance with Bessel's correction applied.
+func (a Accumulator) SampleVariance() float64 {
+	if a.count < 2 {
+		return 0.0
+	}
+	return a.m2 / float64(a.count-1)
+}
+
+// StdDev returns the population standard deviation.
+func (a Accumulator) StdDev() float64 {
+	return math.Sqrt(a.Variance())
+}
+
+// SampleStdDev returns the standard deviation with Bessel's correction applied.
+func (a Accumulator) SampleStdDev() float64 {
+	return math.Sqrt(a.SampleVariance())
+}
@@ -0,0 +1,112 @@
+package stats
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShouldAccumulateStatistics(t *testing.T) {
+	tests := []struct {
+		name                 string
+		input                []int
+		expectedCount        int
+		expectedMean         float64
+		expectedStdDev       float64
+		expectedSampleStdDev float64
+	}{
+		{
+			name:                 "empty input",
+			input:                nil,
+			expectedCount:        0,
+			expectedMean:         0.0,
+			expectedStdDev:       0.0,
+			expectedSampleStdDev: 0.0,
+		},
+		{
+			name:                 "one element",
+			input:                []int{3},
+			expectedCount:        1,
+			expectedMean:         3.0,
+			expectedStdDev:       0.0,
+			expectedSampleStdDev: 0.0,
+		},
+		{
+			name:                 "multiple elements",
+			input:                []int{1, 2, 3, 4, 5, 6, 7, 8, 9},
+			expectedCount:        9,
+			expectedMean:         5.0,
+			expectedStdDev:       2.581988897471611,
+			expectedSampleStdDev: 2.7386127875258306,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// when
+			acc := Accumulate(test.input...)
+
+			// then
+			assert.Equal(t, test.expectedCount, acc.Count())
+			assert.InDelta(t, test.expectedMean, acc.Mean(), 1e-12)
+			assert.InDelta(t, test.expectedStdDev, acc.StdDev(), 1e-12)
+			assert.InDelta(t, test.expectedSampleStdDev, acc.SampleStdDev(), 1e-12)
+		})
+	}
+}
+
+func TestShouldMergeAccumulators(t *testing.T) {
+	tests := []struct {
+		name  string
+		left  []int
+		right []int
+	}{
+		{
+			name:  "both empty",
+			left:  nil,
+			right: nil,
+		},
+		{
+			name:  "left empty",
+			left:  nil,
+			right: []int{1, 2, 3},
+		},
+		{
+			name:  "right empty",
+			left:  []int{1, 2, 3},
+			right: nil,
+		},
+		{
+			name:  "both filled",
+			left:  []int{1, 2, 3, 4, 5},
+			right: []int{6, 7, 8, 9},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// given
+			expected := Accumulate(append(append([]int{}, test.left...), test.right...)...)
+			sut := Accumulate(test.left...)
+
+			// when
+			sut.Merge(Accumulate(test.right...))
+
+			// then
+			assert.Equal(t, expected.Count(), sut.Count())
+			assert.InDelta(t, expected.Mean(), sut.Mean(), 1e-12)
+			assert.InDelta(t, expected.Variance(), sut.Variance(), 1e-12)
+			assert.InDelta(t, expected.SampleVariance(), sut.SampleVariance(), 1e-12)
+		})
+	}
+}
+
+func TestShouldKeepPrecisionForLargeOffsets(t *testing.T) {
+	// given
+	offset := 1e9
+	input := []float64{offset + 4, offset + 7, offset + 13, offset + 16}
+
+	// when
+	acc := Accumulate(input...)
+
+	// then
+	assert.InDelta(t, 22.5, acc.Variance(), 1e-6)
+}
@@ -2,6 +2,7 @@ package stats
 
 import (
 	"math"
+	"slices"
 
 	"golang.org/x/exp/constraints"
 )
@@ -22,13 +23,77 @@ func ArithmeticMean[T Numbers](numbers ...T) float64 {
 }
 
 func StandardDeviation[T Numbers](numbers ...T) float64 {
+	return math.Sqrt(Variance(numbers...))
+}
+
+func SampleStandardDeviation[T Numbers](numbers ...T) float64 {
+	return math.Sqrt(SampleVariance(numbers...))
+}
+
+func Variance[T Numbers](numbers ...T) float64 {
 	if len(numbers) == 0 {
 		return 0.0
 	}
+	return sumOfSquaredDeviations(numbers...) / float64(len(numbers))
+}
+
+func SampleVariance[T Numbers](numbers ...T) float64 {
+	if len(numbers) < 2 {
+		return 0.0
+	}
+	return sumOfSquaredDeviations(numbers...) / float64(len(numbers)-1)
+}
+
+func sumOfSquaredDeviations[T Numbers](numbers ...T) float64 {
 	mean := ArithmeticMean(numbers...)
 	sum := 0.0
 	for _, number := range numbers {
 		sum += math.Pow(float64(number)-mean, 2)
 	}
-	return math.Sqrt(sum / float64(len(numbers)))
+	return sum
+}
+
+func Sum[T Numbers](numbers ...T) T {
+	var sum T
+	for _, number := range numbers {
+		sum += number
+	}
+	return sum
+}
+
+func Min[T Numbers](numbers ...T) T {
+	var min T
+	for i, number := range numbers {
+		if i == 0 || number < min {
+			min = number
+		}
+	}
+	return min
+}
+
+func Max[T Numbers](numbers ...T) T {
+	var max T
+	for i, number := range numbers {
+		if i == 0 || number > max {
+			max = number
+		}
+	}
+	return max
+}
+
+func Range[T Numbers](numbers ...T) T {
+	return Max(numbers...) - Min(numbers...)
+}
+
+func Median[T Numbers](numbers ...T) float64 {
+	if len(numbers) == 0 {
+		return 0.0
+	}
+	sorted := slices.Clone(numbers)
+	slices.Sort(sorted)
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return float64(sorted[middle])
+	}
+	return (float64(sorted[middle-1]) + float64(sorted[middle])) / 2
 }
@@ -1,6 +1,7 @@
 package stats
 
 import (
+	"slices"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -71,3 +72,118 @@ func TestShouldReturnStandardDeviation(t *testing.T) {
 		})
 	}
 }
+
+func TestShouldReturnSampleStandardDeviation(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []int
+		expected float64
+	}{
+		{
+			name:     "empty input",
+			input:    nil,
+			expected: 0.0,
+		},
+		{
+			name:     "one element",
+			input:    []int{3},
+			expected: 0.0,
+		},
+		{
+			name:     "multiple elements",
+			input:    []int{1, 2, 3, 4, 5, 6, 7, 8, 9},
+			expected: 2.7386127875258306,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// when
+			res := SampleStandardDeviation(test.input...)
+
+			// then
+			assert.Equal(t, test.expected, res)
+		})
+	}
+}
+
+func TestShouldReturnDescriptiveStatistics(t *testing.T) {
+	tests := []struct {
+		name                   string
+		input                  []int
+		expectedVariance       float64
+		expectedSampleVariance float64
+		expectedSum            int
+		expectedMin            int
+		expectedMax            int
+		expectedRange          int
+		expectedMedian         float64
+	}{
+		{
+			name:                   "empty input",
+			input:                  nil,
+			expectedVariance:       0.0,
+			expectedSampleVariance: 0.0,
+			expectedSum:            0,
+			expectedMin:            0,
+			expectedMax:            0,
+			expectedRange:          0,
+			expectedMedian:         0.0,
+		},
+		{
+			name:                   "one element",
+			input:                  []int{-3},
+			expectedVariance:       0.0,
+			expectedSampleVariance: 0.0,
+			expectedSum:            -3,
+			expectedMin:            -3,
+			expectedMax:            -3,
+			expectedRange:          0,
+			expectedMedian:         -3.0,
+		},
+		{
+			name:                   "odd number of elements",
+			input:                  []int{9, 1, 8, 2, 7, 3, 6, 4, 5},
+			expectedVariance:       6.666666666666667,
+			expectedSampleVariance: 7.5,
+			expectedSum:            45,
+			expectedMin:            1,
+			expectedMax:            9,
+			expectedRange:          8,
+			expectedMedian:         5.0,
+		},
+		{
+			name:                   "even number of elements",
+			input:                  []int{4, -2, 7, 1},
+			expectedVariance:       11.25,
+			expectedSampleVariance: 15.0,
+			expectedSum:            10,
+			expectedMin:            -2,
+			expectedMax:            7,
+			expectedRange:          9,
+			expectedMedian:         2.5,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// when
+			input := slices.Clone(test.input)
+			variance := Variance(input...)
+			sampleVariance := SampleVariance(input...)
+			sum := Sum(input...)
+			min := Min(input...)
+			max := Max(input...)
+			rng := Range(input...)
+			median := Median(input...)
+
+			// then
+			assert.InDelta(t, test.expectedVariance, variance, 1e-12)
+			assert.InDelta(t, test.expectedSampleVariance, sampleVariance, 1e-12)
+			assert.Equal(t, test.expectedSum, sum)
+			assert.Equal(t, test.expectedMin, min)
+			assert.Equal(t, test.expectedMax, max)
+			assert.Equal(t, test.expectedRange, rng)
+			assert.Equal(t, test.expectedMedian, median)
+			assert.Equal(t, test.input, input, "input must not be reordered")
+		})
+	}
+}
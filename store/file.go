@@ -0,0 +1,219 @@
+package store
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/slog"
+)
+
+const (
+	// FileName is the name of the file holding the records within the data dir.
+	FileName = "results.jsonl"
+
+	DefaultLimit = 100
+)
+
+type entry struct {
+	requestID string
+	timestamp time.Time
+	offset    int64
+	size      int
+}
+
+// File appends every record as a JSON line to results.jsonl in a data dir.
+// Only an index of the records is kept in memory, the records themselves are
+// read from the file when requested.
+type File struct {
+	mu      sync.RWMutex
+	f       *os.File
+	size    int64
+	entries []entry
+	// byID points at the entry of every request id.
+	byID map[string]int
+}
+
+// OpenFile opens the store in dir, creating both if needed. A last record
+// left incomplete by a crash is discarded.
+func OpenFile(dir string) (*File, error) {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the data dir: %w", err)
+	}
+	f, err := os.OpenFile(filepath.Join(dir, FileName), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the result store: %w", err)
+	}
+	s := &File{
+		f:    f,
+		byID: make(map[string]int),
+	}
+	err = s.load()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *File) load() error {
+	r := bufio.NewReader(s.f)
+	var offset int64
+	for {
+		line, err := r.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(line) > 0 {
+				slog.Warn("discarding incomplete last result", "timestamp", time.Now(), "offset", offset, "bytes", len(line))
+				err = s.f.Truncate(offset)
+				if err != nil {
+					return fmt.Errorf("failed to discard the incomplete last result: %w", err)
+				}
+			}
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read the result store: %w", err)
+		}
+		var header struct {
+			RequestID string    `json:"request_id"`
+			Timestamp time.Time `json:"timestamp"`
+		}
+		err = json.Unmarshal(line, &header)
+		if err != nil {
+			return fmt.Errorf("%w: record %d: %w", ErrCorrupt, len(s.entries)+1, err)
+		}
+		s.add(entry{
+			requestID: header.RequestID,
+			timestamp: header.Timestamp,
+			offset:    offset,
+			size:      len(line) - 1,
+		})
+		offset += int64(len(line))
+	}
+	s.size = offset
+	return nil
+}
+
+func (s *File) add(e entry) {
+	s.entries = append(s.entries, e)
+	s.byID[e.requestID] = len(s.entries) - 1
+}
+
+// Save appends the record unless one of the same request id was saved before,
+// which is kept and ErrExists returned instead.
+func (s *File) Save(record Record) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode the result: %w", err)
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.byID[record.RequestID]; ok {
+		return fmt.Errorf("%w: %s", ErrExists, record.RequestID)
+	}
+	_, err = s.f.WriteAt(line, s.size)
+	if err != nil {
+		// do not leave a partial line behind the next record
+		if truncErr := s.f.Truncate(s.size); truncErr != nil {
+			err = errors.Join(err, truncErr)
+		}
+		return fmt.Errorf("failed to write the result: %w", err)
+	}
+	s.add(entry{
+		requestID: record.RequestID,
+		timestamp: record.Timestamp,
+		offset:    s.size,
+		size:      len(line) - 1,
+	})
+	s.size += int64(len(line))
+	return nil
+}
+
+// Get returns the record of the request.
+func (s *File) Get(requestID string) (Record, error) {
+	s.mu.RLock()
+	i, ok := s.byID[requestID]
+	var e entry
+	if ok {
+		e = s.entries[i]
+	}
+	s.mu.RUnlock()
+	if !ok {
+		return Record{}, ErrNotFound
+	}
+	return s.read(e)
+}
+
+// List returns the records matching the query in the order they were saved.
+// A cursor is the index of the record, counted from the first one saved, at
+// which the next page starts.
+func (s *File) List(query Query) (Page, error) {
+	limit := query.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+
+	var page Page
+	var selected []entry
+	s.mu.RLock()
+	for i := query.Cursor; i < uint64(len(s.entries)); i++ {
+		e := s.entries[i]
+		if !query.From.IsZero() && e.timestamp.Before(query.From) {
+			continue
+		}
+		if !query.To.IsZero() && !e.timestamp.Before(query.To) {
+			continue
+		}
+		if len(selected) == limit {
+			page.NextCursor = i
+			break
+		}
+		selected = append(selected, e)
+	}
+	s.mu.RUnlock()
+
+	page.Records = make([]Record, 0, len(selected))
+	for _, e := range selected {
+		record, err := s.read(e)
+		if err != nil {
+			return Page{}, err
+		}
+		page.Records = append(page.Records, record)
+	}
+	return page, nil
+}
+
+func (s *File) read(e entry) (Record, error) {
+	line := make([]byte, e.size)
+	_, err := s.f.ReadAt(line, e.offset)
+	if err != nil {
+		return Record{}, fmt.Errorf("failed to read the result: %w", err)
+	}
+	var record Record
+	err = json.Unmarshal(line, &record)
+	if err != nil {
+		return Record{}, fmt.Errorf("%w: %w", ErrCorrupt, err)
+	}
+	return record, nil
+}
+
+// Close flushes the records to disk and closes the file.
+func (s *File) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := s.f.Sync()
+	if err != nil {
+		s.f.Close()
+		return fmt.Errorf("failed to flush the result store: %w", err)
+	}
+	return s.f.Close()
+}
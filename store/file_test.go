@@ -0,0 +1,167 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/koenno/standard-deviation-service/service"
+	"github.com/stretchr/testify/assert"
+)
+
+var epoch = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func record(requestID string, timestamp time.Time) Record {
+	return Record{
+		RequestID: requestID,
+		Timestamp: timestamp,
+		Params: Params{
+			Kind: service.Population,
+			Sets: []SetParams{{Length: 2, Min: 1, Max: 10, Source: "local"}},
+		},
+		Sets:     []service.StdDevResult{{StdDev: 1, Kind: service.Population, Data: []int{1, 3}}},
+		Combined: &service.StdDevResult{StdDev: 1, Kind: service.Population, Data: []int{1, 3}},
+	}
+}
+
+func openFile(t *testing.T, dir string) *File {
+	t.Helper()
+	sut, err := OpenFile(dir)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		sut.Close()
+	})
+	return sut
+}
+
+func TestShouldKeepRecordsAcrossRestarts(t *testing.T) {
+	// given
+	dir := filepath.Join(t.TempDir(), "data")
+	first := openFile(t, dir)
+	expected := record("req-1", epoch)
+	assert.NoError(t, first.Save(expected))
+	assert.NoError(t, first.Save(record("req-2", epoch.Add(time.Second))))
+	assert.NoError(t, first.Close())
+
+	// when
+	sut := openFile(t, dir)
+	actual, err := sut.Get("req-1")
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, expected, actual)
+	assert.NoError(t, sut.Save(record("req-3", epoch.Add(2*time.Second))))
+	page, err := sut.List(Query{})
+	assert.NoError(t, err)
+	assert.Len(t, page.Records, 3)
+}
+
+func TestShouldKeepFirstRecordOfRequest(t *testing.T) {
+	// given
+	sut := openFile(t, t.TempDir())
+	assert.NoError(t, sut.Save(record("req-1", epoch)))
+
+	// when
+	err := sut.Save(record("req-1", epoch.Add(time.Second)))
+
+	// then
+	assert.ErrorIs(t, err, ErrExists)
+	actual, err := sut.Get("req-1")
+	assert.NoError(t, err)
+	assert.Equal(t, epoch, actual.Timestamp)
+	page, err := sut.List(Query{})
+	assert.NoError(t, err)
+	assert.Len(t, page.Records, 1)
+}
+
+func TestShouldReturnErrNotFoundForUnknownRequest(t *testing.T) {
+	// given
+	sut := openFile(t, t.TempDir())
+
+	// when
+	_, err := sut.Get("req-1")
+
+	// then
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestShouldListRecordsWithinTimeRangeInPages(t *testing.T) {
+	// given
+	sut := openFile(t, t.TempDir())
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, sut.Save(record(fmt.Sprintf("req-%d", i), epoch.Add(time.Duration(i)*time.Minute))))
+	}
+	query := Query{
+		From:  epoch.Add(2 * time.Minute),
+		To:    epoch.Add(9 * time.Minute),
+		Limit: 3,
+	}
+
+	// when
+	var pages [][]string
+	for {
+		page, err := sut.List(query)
+		assert.NoError(t, err)
+		var ids []string
+		for _, r := range page.Records {
+			ids = append(ids, r.RequestID)
+		}
+		pages = append(pages, ids)
+		if page.NextCursor == 0 {
+			break
+		}
+		query.Cursor = page.NextCursor
+	}
+
+	// then
+	expected := [][]string{
+		{"req-2", "req-3", "req-4"},
+		{"req-5", "req-6", "req-7"},
+		{"req-8"},
+	}
+	assert.Equal(t, expected, pages)
+}
+
+func TestShouldDiscardIncompleteLastRecord(t *testing.T) {
+	// given
+	dir := t.TempDir()
+	first := openFile(t, dir)
+	assert.NoError(t, first.Save(record("req-1", epoch)))
+	assert.NoError(t, first.Close())
+	f, err := os.OpenFile(filepath.Join(dir, FileName), os.O_APPEND|os.O_WRONLY, 0)
+	assert.NoError(t, err)
+	_, err = f.WriteString(`{"request_id":"req-2","times`)
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	// when
+	sut := openFile(t, dir)
+	err = sut.Save(record("req-3", epoch))
+
+	// then
+	assert.NoError(t, err)
+	page, err := sut.List(Query{})
+	assert.NoError(t, err)
+	if assert.Len(t, page.Records, 2) {
+		assert.Equal(t, "req-1", page.Records[0].RequestID)
+		assert.Equal(t, "req-3", page.Records[1].RequestID)
+	}
+	_, err = sut.Get("req-2")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestShouldRefuseToOpenCorruptStore(t *testing.T) {
+	// given
+	dir := t.TempDir()
+	content := "{\"request_id\":\"req-1\"}\nnot json\n{\"request_id\":\"req-3\"}\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, FileName), []byte(content), 0o644))
+
+	// when
+	_, err := OpenFile(dir)
+
+	// then
+	assert.ErrorIs(t, err, ErrCorrupt)
+	assert.ErrorContains(t, err, "record 2")
+}
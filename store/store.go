@@ -0,0 +1,52 @@
+package store
+
+import (
+	"errors"
+	"time"
+
+	"github.com/koenno/standard-deviation-service/service"
+)
+
+var (
+	ErrNotFound = errors.New("result not found")
+	ErrExists   = errors.New("result already recorded")
+	ErrCorrupt  = errors.New("corrupt result store")
+)
+
+// Record is everything needed to audit a single calculation: the parameters
+// it was requested with and the integers every set consisted of.
+type Record struct {
+	RequestID string                 `json:"request_id"`
+	Timestamp time.Time              `json:"timestamp"`
+	Params    Params                 `json:"params"`
+	Sets      []service.StdDevResult `json:"sets"`
+	Combined  *service.StdDevResult  `json:"combined,omitempty"`
+}
+
+type Params struct {
+	Kind   service.Kind    `json:"kind"`
+	Fields []service.Field `json:"fields,omitempty"`
+	Sets   []SetParams     `json:"sets"`
+}
+
+type SetParams struct {
+	Length int    `json:"length"`
+	Min    int    `json:"min"`
+	Max    int    `json:"max"`
+	Source string `json:"source"`
+}
+
+// Query selects records stored within [From, To), oldest first. Zero times
+// leave the range open. Cursor continues a previous listing.
+type Query struct {
+	From   time.Time
+	To     time.Time
+	Cursor uint64
+	Limit  int
+}
+
+// Page is a single page of a listing. NextCursor is zero on the last page.
+type Page struct {
+	Records    []Record
+	NextCursor uint64
+}
@@ -0,0 +1,38 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+)
+
+func TestShouldRejectUnknownExporter(t *testing.T) {
+	// when
+	shutdown, err := Setup(context.Background(), Settings{Exporter: "zipkin"})
+
+	// then
+	assert.ErrorIs(t, err, ErrUnknownExporter)
+	assert.Nil(t, shutdown)
+}
+
+func TestShouldExportSpansToStdout(t *testing.T) {
+	// given
+	previous := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(previous)
+	var out bytes.Buffer
+	shutdown, err := Setup(context.Background(), Settings{Exporter: ExporterStdout, ServiceName: "test-service", Stdout: &out})
+	assert.NoError(t, err)
+
+	// when
+	_, span := otel.Tracer("test").Start(context.Background(), "test-span")
+	span.End()
+	err = shutdown(context.Background())
+
+	// then
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), `"Name": "test-span"`)
+	assert.Contains(t, out.String(), "test-service")
+}